@@ -53,6 +53,39 @@ type NoPingConfig struct {
 
 	// DeleteMessage: delete the offending message (default true).
 	DeleteMessage bool `json:"delete_message"`
+
+	// ExemptRoles may ping protected roles freely (e.g. staff pinging staff).
+	ExemptRoles []string `json:"exempt_roles"`
+
+	// ExemptChannels are channels where the rule doesn't apply.
+	ExemptChannels []string `json:"exempt_channels"`
+
+	// AllowReplyPings lets replies to protected members keep their reply ping.
+	// When false, ReplyMessage is sent instead of Message (same placeholders).
+	AllowReplyPings bool   `json:"allow_reply_pings"`
+	ReplyMessage    string `json:"reply_message"`
+
+	// Escalation turns repeated offences within EscalationWindowMinutes into
+	// warnings or timeouts. The step with the highest Offences not above the
+	// member's count applies.
+	Escalation              []NoPingEscalationStep `json:"escalation"`
+	EscalationWindowMinutes int                    `json:"escalation_window_minutes"`
+
+	// GhostPing reposts who was pinged when a message with mentions is deleted
+	// or edited to drop them within GhostPingWindowMinutes of being sent.
+	GhostPing              bool `json:"ghost_ping"`
+	GhostPingWindowMinutes int  `json:"ghost_ping_window_minutes"`
+
+	// MaxMentions removes messages that mention more than this many users and
+	// roles and counts them towards Escalation. 0 (the default) turns it off.
+	MaxMentions int `json:"max_mentions"`
+}
+
+// NoPingEscalationStep is one rung of the NoPing escalation ladder.
+type NoPingEscalationStep struct {
+	Offences int    `json:"offences"`
+	Action   string `json:"action"`             // "warn" or "timeout"
+	Duration string `json:"duration,omitempty"` // timeout length, e.g. "10m"
 }
 
 // CountingGameConfig configures the counting minigame channel.
@@ -169,6 +202,17 @@ type ModerationConfig struct {
 	ModLogChannel string        `json:"mod_log_channel"`
 	MuteRole      string        `json:"mute_role"`
 	AutoMod       AutoModConfig `json:"auto_mod"`
+
+	// PunishmentRoles are always given back to members who leave and rejoin,
+	// alongside the mute role, so a rejoin can't be used to shed a punishment.
+	PunishmentRoles []string `json:"punishment_roles"`
+
+	AntiRaid AntiRaidConfig `json:"anti_raid"`
+	JoinGate JoinGateConfig `json:"join_gate"`
+	Nickname NicknameConfig `json:"nicknames"`
+
+	// PhishingListPath is a text file of known phishing domains, one per line.
+	PhishingListPath string `json:"phishing_list_path"`
 }
 
 type WelcomeLeaveConfig struct {
@@ -188,12 +232,59 @@ type EmbedConfig struct {
 
 type AutoModConfig struct {
 	Enabled         bool `json:"enabled"`
-	MaxMentions     int  `json:"max_mentions"`
+	MaxMentions     int  `json:"max_mentions"` // unused; see NoPingConfig.MaxMentions
 	MaxLines        int  `json:"max_lines"`
 	AntiSpamSeconds int  `json:"anti_spam_seconds"`
 	AntiSpamCount   int  `json:"anti_spam_count"`
 }
 
+// AntiRaidConfig controls join-burst detection. When more than JoinThreshold
+// members join within JoinWindowSeconds the guild is put into raid mode.
+type AntiRaidConfig struct {
+	Enabled           bool     `json:"enabled"`
+	JoinThreshold     int      `json:"join_threshold"`
+	JoinWindowSeconds int      `json:"join_window_seconds"`
+	LockChannels      []string `json:"lock_channels"`
+	VerificationLevel int      `json:"verification_level"` // 0-4, raised to this during a raid
+	AlertRole         string   `json:"alert_role"`
+	// Action is applied to burst joiners younger than MaxAccountAgeDays:
+	// "none", "kick" or "ban".
+	Action            string `json:"action"`
+	MaxAccountAgeDays int    `json:"max_account_age_days"`
+}
+
+// JoinGateConfig flags new members whose accounts are younger than
+// MinAccountAgeDays and, with AltDetection, those who look like a user banned
+// in the last AltWindowDays.
+type JoinGateConfig struct {
+	Enabled           bool `json:"enabled"`
+	MinAccountAgeDays int  `json:"min_account_age_days"`
+	// Action for young accounts: "log", "quarantine" or "kick".
+	Action         string `json:"action"`
+	QuarantineRole string `json:"quarantine_role"`
+	// JoinLogChannel gets an entry for every join; flagged joins fall back to
+	// the mod log when it's empty.
+	JoinLogChannel string `json:"join_log_channel"`
+	AltDetection   bool   `json:"alt_detection"`
+	AltWindowDays  int    `json:"alt_window_days"`
+}
+
+// NicknameConfig controls automatic clean-up of member display names.
+type NicknameConfig struct {
+	Enabled bool `json:"enabled"`
+	// Dehoist strips leading HoistCharacters that push names to the top of
+	// the member list.
+	Dehoist         bool   `json:"dehoist"`
+	HoistCharacters string `json:"hoist_characters"`
+	// Normalize folds styled unicode letters (fullwidth, mathematical,
+	// circled) to plain ones and drops symbols, invisible characters and
+	// stacked combining marks. Other scripts and accents are kept.
+	Normalize bool `json:"normalize"`
+	// Placeholder replaces names left blank or made only of symbols and
+	// invisible characters, which nobody can type to mention.
+	Placeholder string `json:"placeholder"`
+}
+
 type TicketsConfig struct {
 	Enabled         bool             `json:"enabled"`
 	PanelChannel    string           `json:"panel_channel"`
@@ -220,9 +311,145 @@ type TicketSubcategory struct {
 	Description string `json:"description"`
 }
 
+// StickyRoleState controls which roles are given back to members who leave and rejoin.
+type StickyRoleState struct {
+	Enabled bool     `json:"enabled"`
+	RoleIDs []string `json:"role_ids"`
+}
+
 type AutoRoleState struct {
-	Enabled bool   `json:"enabled"`
-	RoleID  string `json:"role_id"`
+	Enabled bool `json:"enabled"`
+	// RoleID is the single join role used by older configs. LoadGuildState
+	// moves it into RoleIDs.
+	RoleID     string   `json:"role_id,omitempty"`
+	RoleIDs    []string `json:"role_ids"`
+	BotRoleIDs []string `json:"bot_role_ids"`
+	// Delay postpones assignment to human members (e.g. "10m"). Empty means immediate.
+	Delay            string `json:"delay,omitempty"`
+	WaitForScreening bool   `json:"wait_for_screening"`
+}
+
+// VerificationState gates new members behind a challenge in a verify channel.
+type VerificationState struct {
+	Enabled          bool   `json:"enabled"`
+	ChannelID        string `json:"channel_id"`
+	MessageID        string `json:"message_id"`
+	QuarantineRoleID string `json:"quarantine_role_id"`
+	Mode             string `json:"mode"`    // "button", "text" or "math"
+	Timeout          string `json:"timeout"` // e.g. "10m"; empty means no kick
+}
+
+// PendingVerification is a member who joined while verification was enabled
+// and has not passed the challenge yet.
+type PendingVerification struct {
+	UserID    string `json:"user_id"`
+	ExpiresAt string `json:"expires_at,omitempty"` // RFC3339
+	Answer    string `json:"answer,omitempty"`
+	Attempts  int    `json:"attempts"`
+}
+
+// FilterRule is one entry of the per-guild content filter.
+type FilterRule struct {
+	ID             int      `json:"id"`
+	Pattern        string   `json:"pattern"`
+	Type           string   `json:"type"`   // "word", "wildcard" or "regex"
+	Action         string   `json:"action"` // "delete", "warn", "timeout" or "log"
+	Duration       string   `json:"duration,omitempty"`
+	ExemptChannels []string `json:"exempt_channels"`
+	ExemptRoles    []string `json:"exempt_roles"`
+	CreatedBy      string   `json:"created_by"`
+}
+
+// LinkFilterState controls invite and link blocking for a guild.
+type LinkFilterState struct {
+	Enabled        bool     `json:"enabled"`
+	BlockInvites   bool     `json:"block_invites"`
+	BlockLinks     bool     `json:"block_links"`
+	AllowedDomains []string `json:"allowed_domains"`
+	Action         string   `json:"action"` // same values as FilterRule.Action
+	Duration       string   `json:"duration,omitempty"`
+	ExemptChannels []string `json:"exempt_channels"`
+	ExemptRoles    []string `json:"exempt_roles"`
+}
+
+// AppealState controls where ban and mute appeals are sent for review.
+type AppealState struct {
+	Enabled   bool   `json:"enabled"`
+	ChannelID string `json:"channel_id"`
+}
+
+// ReportState configures where "Report message" reports are reviewed.
+type ReportState struct {
+	ChannelID string `json:"channel_id"`
+	Counter   int    `json:"counter"`
+}
+
+// MessageReport is a reported message waiting for staff review. Content is
+// captured when the report is made so it survives edits and deletion.
+type MessageReport struct {
+	ID              int    `json:"id"`
+	ChannelID       string `json:"channel_id"`
+	MessageID       string `json:"message_id"`
+	AuthorID        string `json:"author_id"`
+	ReporterID      string `json:"reporter_id"`
+	Reason          string `json:"reason"`
+	Content         string `json:"content"`
+	ReportMessageID string `json:"report_message_id"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// AuditState configures the server activity feed. Event types listed in
+// Disabled are skipped; everything else is logged once ChannelID is set.
+type AuditState struct {
+	ChannelID string   `json:"channel_id"`
+	Disabled  []string `json:"disabled"`
+}
+
+// BannedUser remembers a recently banned account for alt detection.
+type BannedUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar,omitempty"`
+	BannedAt string `json:"banned_at"`
+}
+
+// ChannelOverwriteSnapshot is a channel's @everyone overwrite as it was before
+// the bot locked the channel, so unlocking can put it back exactly.
+type ChannelOverwriteSnapshot struct {
+	Existed bool  `json:"existed"`
+	Allow   int64 `json:"allow"`
+	Deny    int64 `json:"deny"`
+	// Holders lists the features keeping the channel locked ("manual",
+	// "lockdown", "raid"); the overwrite is restored when the last one lets go.
+	Holders []string `json:"holders,omitempty"`
+}
+
+// LockdownState tracks a /lockdown in progress.
+type LockdownState struct {
+	Active    bool     `json:"active"`
+	Reason    string   `json:"reason,omitempty"`
+	StartedAt string   `json:"started_at,omitempty"` // RFC3339
+	ModID     string   `json:"mod_id,omitempty"`
+	Channels  []string `json:"channels"`
+	Notice    bool     `json:"notice"`
+}
+
+// RaidState records what raid mode changed so /raid end can put it back.
+type RaidState struct {
+	Active         bool     `json:"active"`
+	StartedAt      string   `json:"started_at,omitempty"` // RFC3339
+	LockedChannels []string `json:"locked_channels"`
+	// PreviousVerificationLevel is -1 when raid mode didn't change it.
+	PreviousVerificationLevel int      `json:"previous_verification_level"`
+	JoinerIDs                 []string `json:"joiner_ids"`
+}
+
+// PendingJoinRole is a member whose join roles are held back until membership
+// screening completes and/or a delay has passed.
+type PendingJoinRole struct {
+	UserID         string `json:"user_id"`
+	AwaitScreening bool   `json:"await_screening"`
+	AssignAt       string `json:"assign_at,omitempty"` // RFC3339, set once screening is done
 }
 
 type RoleMenu struct {
@@ -233,12 +460,39 @@ type RoleMenu struct {
 	MessageID    string          `json:"message_id"`
 	SingleSelect bool            `json:"single_select"`
 	Roles        []RoleMenuEntry `json:"roles"`
+
+	// Reactions marks a reaction-role menu: MessageID points at any existing message
+	// and each entry's Emoji is a reaction that toggles its role.
+	Reactions bool `json:"reactions,omitempty"`
 }
 
 type RoleMenuEntry struct {
 	RoleID string `json:"role_id"`
 	Label  string `json:"label"`
 	Emoji  string `json:"emoji"`
+
+	// RequiredRoleID must already be held by the member before this role can be picked.
+	RequiredRoleID string `json:"required_role_id,omitempty"`
+
+	// ExclusionGroup links entries across menus: a member can hold at most one
+	// role from the same group at a time.
+	ExclusionGroup string `json:"exclusion_group,omitempty"`
+
+	// Duration, when set (e.g. "7d"), makes the role expire after being picked.
+	Duration string `json:"duration,omitempty"`
+}
+
+// TempRole is a role grant that is removed automatically at ExpiresAt.
+type TempRole struct {
+	UserID    string `json:"user_id"`
+	RoleID    string `json:"role_id"`
+	ExpiresAt string `json:"expires_at"` // RFC3339
+	GrantedBy string `json:"granted_by"`
+	Reason    string `json:"reason,omitempty"`
+
+	// MenuID is set when the role was picked from a role menu, so a reaction
+	// menu's reaction can be removed along with the role.
+	MenuID string `json:"menu_id,omitempty"`
 }
 
 type Giveaway struct {
@@ -270,7 +524,44 @@ type GuildState struct {
 
 	AutoRole  AutoRoleState `json:"autorole"`
 	RoleMenus []RoleMenu    `json:"role_menus"`
-	Giveaways []Giveaway    `json:"giveaways"`
+	// RoleMenuCounter numbers role menus so IDs aren't reused after a removal.
+	RoleMenuCounter int        `json:"role_menu_counter"`
+	Giveaways       []Giveaway `json:"giveaways"`
+	TempRoles       []TempRole `json:"temp_roles"`
+
+	PendingJoinRoles []PendingJoinRole `json:"pending_join_roles"`
+
+	FilterRules []FilterRule    `json:"filter_rules"`
+	LinkFilter  LinkFilterState `json:"link_filter"`
+
+	Raid     RaidState     `json:"raid"`
+	Lockdown LockdownState `json:"lockdown"`
+	// ChannelLocks holds the original @everyone overwrite of every channel the
+	// bot has locked, keyed by channel ID.
+	ChannelLocks map[string]ChannelOverwriteSnapshot `json:"channel_locks"`
+
+	Verification         VerificationState     `json:"verification"`
+	PendingVerifications []PendingVerification `json:"pending_verifications"`
+
+	Appeals AppealState `json:"appeals"`
+	Audit   AuditState  `json:"audit"`
+
+	// RecentBans feeds alt detection; entries expire after the alt window.
+	RecentBans []BannedUser `json:"recent_bans"`
+
+	Reports     ReportState     `json:"reports"`
+	OpenReports []MessageReport `json:"open_reports"`
+
+	StickyRoles StickyRoleState `json:"sticky_roles"`
+	// SavedRoles holds the roles members had when they left, keyed by user ID.
+	SavedRoles map[string][]string `json:"saved_roles"`
+	// SavedRolesAt records when each SavedRoles entry was written, so entries
+	// for members who never come back can be pruned.
+	SavedRolesAt map[string]string `json:"saved_roles_at"`
+	// PunishedRoles holds the punishment roles each member currently has. It is
+	// kept up to date from member updates, so a punishment survives leaving
+	// even when the member wasn't in the state cache.
+	PunishedRoles map[string][]string `json:"punished_roles"`
 }
 
 type TicketRuntime struct {
@@ -337,6 +628,45 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Music.Lavalink.Password == "" {
 		cfg.Music.Lavalink.Password = "youshallnotpass"
 	}
+	if cfg.NoPing.EscalationWindowMinutes <= 0 {
+		cfg.NoPing.EscalationWindowMinutes = 60
+	}
+	if cfg.NoPing.GhostPingWindowMinutes <= 0 {
+		cfg.NoPing.GhostPingWindowMinutes = 10
+	}
+	if cfg.Moderation.AntiRaid.JoinThreshold <= 0 {
+		cfg.Moderation.AntiRaid.JoinThreshold = 10
+	}
+	if cfg.Moderation.AntiRaid.JoinWindowSeconds <= 0 {
+		cfg.Moderation.AntiRaid.JoinWindowSeconds = 10
+	}
+	if cfg.Moderation.AntiRaid.VerificationLevel <= 0 {
+		cfg.Moderation.AntiRaid.VerificationLevel = 3 // High
+	}
+	if cfg.Moderation.AntiRaid.Action == "" {
+		cfg.Moderation.AntiRaid.Action = "none"
+	}
+	if cfg.Moderation.AntiRaid.MaxAccountAgeDays <= 0 {
+		cfg.Moderation.AntiRaid.MaxAccountAgeDays = 7
+	}
+	if cfg.Moderation.JoinGate.MinAccountAgeDays <= 0 {
+		cfg.Moderation.JoinGate.MinAccountAgeDays = 7
+	}
+	if cfg.Moderation.JoinGate.Action == "" {
+		cfg.Moderation.JoinGate.Action = "log"
+	}
+	if cfg.Moderation.JoinGate.AltWindowDays <= 0 {
+		cfg.Moderation.JoinGate.AltWindowDays = 30
+	}
+	if cfg.Moderation.Nickname.HoistCharacters == "" {
+		cfg.Moderation.Nickname.HoistCharacters = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+	}
+	if cfg.Moderation.Nickname.Placeholder == "" {
+		cfg.Moderation.Nickname.Placeholder = "Moderated Nickname"
+	}
+	if cfg.Moderation.PhishingListPath == "" {
+		cfg.Moderation.PhishingListPath = "data/phishing_domains.txt"
+	}
 	if cfg.Database.Driver == "" {
 		cfg.Database.Driver = "sqlite"
 	}
@@ -366,8 +696,19 @@ func LoadGuildState(guildID string) *GuildState {
 		TicketRuntime: TicketRuntime{
 			OpenTickets: make(map[string]Ticket),
 		},
-		RoleMenus: []RoleMenu{},
-		Giveaways: []Giveaway{},
+		RoleMenus:     []RoleMenu{},
+		Giveaways:     []Giveaway{},
+		TempRoles:     []TempRole{},
+		SavedRoles:    make(map[string][]string),
+		SavedRolesAt:  make(map[string]string),
+		PunishedRoles: make(map[string][]string),
+
+		FilterRules:          []FilterRule{},
+		ChannelLocks:         make(map[string]ChannelOverwriteSnapshot),
+		PendingJoinRoles:     []PendingJoinRole{},
+		PendingVerifications: []PendingVerification{},
+		OpenReports:          []MessageReport{},
+		RecentBans:           []BannedUser{},
 	}
 
 	data, err := os.ReadFile(path)
@@ -387,6 +728,42 @@ func LoadGuildState(guildID string) *GuildState {
 	if gs.Giveaways == nil {
 		gs.Giveaways = []Giveaway{}
 	}
+	if gs.TempRoles == nil {
+		gs.TempRoles = []TempRole{}
+	}
+	if gs.SavedRoles == nil {
+		gs.SavedRoles = make(map[string][]string)
+	}
+	if gs.SavedRolesAt == nil {
+		gs.SavedRolesAt = make(map[string]string)
+	}
+	if gs.PunishedRoles == nil {
+		gs.PunishedRoles = make(map[string][]string)
+	}
+	if gs.PendingJoinRoles == nil {
+		gs.PendingJoinRoles = []PendingJoinRole{}
+	}
+	if gs.FilterRules == nil {
+		gs.FilterRules = []FilterRule{}
+	}
+	if gs.ChannelLocks == nil {
+		gs.ChannelLocks = make(map[string]ChannelOverwriteSnapshot)
+	}
+	if gs.PendingVerifications == nil {
+		gs.PendingVerifications = []PendingVerification{}
+	}
+	if gs.OpenReports == nil {
+		gs.OpenReports = []MessageReport{}
+	}
+	if gs.RecentBans == nil {
+		gs.RecentBans = []BannedUser{}
+	}
+	if gs.AutoRole.RoleID != "" {
+		if len(gs.AutoRole.RoleIDs) == 0 {
+			gs.AutoRole.RoleIDs = []string{gs.AutoRole.RoleID}
+		}
+		gs.AutoRole.RoleID = ""
+	}
 	return gs
 }
 
@@ -467,3 +844,10 @@ func EffectiveModLogChannel(cfg *Config, gs *GuildState) string {
 	}
 	return cfg.Moderation.ModLogChannel
 }
+
+func EffectiveMuteRole(cfg *Config, gs *GuildState) string {
+	if gs.MuteRoleOverride != "" {
+		return gs.MuteRoleOverride
+	}
+	return cfg.Moderation.MuteRole
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type raidJoin struct {
+	userID string
+	at     time.Time
+}
+
+var (
+	// raidJoins holds the recent joins per guild, oldest first.
+	raidJoins   = make(map[string][]raidJoin)
+	raidJoinsMu sync.Mutex
+)
+
+func raidCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "raid",
+			Description:              "Inspect or end raid mode",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "status",
+					Description: "Show whether raid mode is active and the current join rate",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "end",
+					Description: "End raid mode and restore locked channels and the verification level",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+func RegisterAntiRaid(s *discordgo.Session, cfg *config.Config) {
+	if !cfg.Moderation.AntiRaid.Enabled {
+		return
+	}
+
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		handleRaidJoin(s, m, &cfg.Moderation.AntiRaid)
+	})
+
+	log.Printf("[AntiRaid] Active — more than %d joins in %ds triggers raid mode",
+		cfg.Moderation.AntiRaid.JoinThreshold, cfg.Moderation.AntiRaid.JoinWindowSeconds)
+}
+
+func handleRaidCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "status":
+		handleRaidStatus(s, i)
+	case "end":
+		if !endRaid(s, i.GuildID, i.Member.User) {
+			respond(s, i, lang.T("raid_not_active"), true)
+			return
+		}
+		respond(s, i, lang.T("raid_ended"), false)
+	}
+}
+
+func handleRaidStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := &storage.Cfg.Moderation.AntiRaid
+	recent := recentJoinCount(i.GuildID, time.Duration(cfg.JoinWindowSeconds)*time.Second)
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	raid := gs.Raid
+	joiners := len(raid.JoinerIDs)
+	locked := append([]string(nil), raid.LockedChannels...)
+	gs.Unlock()
+
+	if !raid.Active {
+		respond(s, i, lang.T("raid_status_inactive",
+			"recent", strconv.Itoa(recent),
+			"threshold", strconv.Itoa(cfg.JoinThreshold),
+			"window", strconv.Itoa(cfg.JoinWindowSeconds),
+		), true)
+		return
+	}
+
+	startedAt, _ := time.Parse(time.RFC3339, raid.StartedAt)
+	channels := "—"
+	if len(locked) > 0 {
+		parts := make([]string, len(locked))
+		for idx, id := range locked {
+			parts[idx] = "<#" + id + ">"
+		}
+		channels = strings.Join(parts, ", ")
+	}
+	respond(s, i, lang.T("raid_status_active",
+		"timestamp", fmt.Sprintf("%d", startedAt.Unix()),
+		"joiners", strconv.Itoa(joiners),
+		"channels", channels,
+		"action", cfg.Action,
+	), true)
+}
+
+// handleRaidJoin records a join and starts raid mode when the burst threshold
+// is crossed. While a raid is active every new joiner is treated as part of it.
+func handleRaidJoin(s *discordgo.Session, m *discordgo.GuildMemberAdd, cfg *config.AntiRaidConfig) {
+	if m.User == nil || m.User.Bot {
+		return
+	}
+	window := time.Duration(cfg.JoinWindowSeconds) * time.Second
+	now := time.Now()
+
+	raidJoinsMu.Lock()
+	joins := append(raidJoins[m.GuildID], raidJoin{userID: m.User.ID, at: now})
+	cut := 0
+	for cut < len(joins) && now.Sub(joins[cut].at) > window {
+		cut++
+	}
+	joins = joins[cut:]
+	raidJoins[m.GuildID] = joins
+	burst := make([]string, len(joins))
+	for idx, j := range joins {
+		burst[idx] = j.userID
+	}
+	raidJoinsMu.Unlock()
+
+	gs := storage.GetGuild(m.GuildID)
+	gs.Lock()
+	active := gs.Raid.Active
+	if active {
+		gs.Raid.JoinerIDs = append(gs.Raid.JoinerIDs, m.User.ID)
+	}
+	gs.Unlock()
+
+	if active {
+		_ = gs.Save()
+		actOnRaider(s, m.GuildID, m.User, cfg)
+		return
+	}
+	if len(burst) > cfg.JoinThreshold {
+		startRaid(s, m.GuildID, burst, cfg)
+	}
+}
+
+func recentJoinCount(guildID string, window time.Duration) int {
+	raidJoinsMu.Lock()
+	defer raidJoinsMu.Unlock()
+	count := 0
+	for _, j := range raidJoins[guildID] {
+		if time.Since(j.at) <= window {
+			count++
+		}
+	}
+	return count
+}
+
+// startRaid locks the configured channels, raises the verification level,
+// alerts moderators and deals with the accounts that joined in the burst.
+func startRaid(s *discordgo.Session, guildID string, burst []string, cfg *config.AntiRaidConfig) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	if gs.Raid.Active {
+		gs.Unlock()
+		return
+	}
+	gs.Raid = config.RaidState{
+		Active:                    true,
+		StartedAt:                 time.Now().Format(time.RFC3339),
+		LockedChannels:            []string{},
+		PreviousVerificationLevel: -1,
+		JoinerIDs:                 append([]string(nil), burst...),
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	log.Printf("[AntiRaid] Raid detected in guild %s: %d joins", guildID, len(burst))
+
+	locked := make([]string, 0, len(cfg.LockChannels))
+	for _, chID := range cfg.LockChannels {
+		if err := lockChannel(s, guildID, chID, lockHolderRaid); err != nil {
+			log.Printf("[AntiRaid] Failed to lock channel %s: %v", chID, err)
+			continue
+		}
+		locked = append(locked, chID)
+	}
+
+	prevLevel := -1
+	if guild, err := s.Guild(guildID); err == nil && int(guild.VerificationLevel) < cfg.VerificationLevel {
+		level := discordgo.VerificationLevel(cfg.VerificationLevel)
+		if _, err := s.GuildEdit(guildID, &discordgo.GuildParams{VerificationLevel: &level}); err != nil {
+			log.Printf("[AntiRaid] Failed to raise verification level: %v", err)
+		} else {
+			prevLevel = int(guild.VerificationLevel)
+		}
+	}
+
+	gs.Lock()
+	gs.Raid.LockedChannels = locked
+	gs.Raid.PreviousVerificationLevel = prevLevel
+	gs.Unlock()
+	_ = gs.Save()
+
+	sendRaidAlert(s, guildID, len(burst), len(locked), prevLevel >= 0, cfg)
+
+	if cfg.Action != "kick" && cfg.Action != "ban" {
+		return
+	}
+	for _, userID := range burst {
+		user, err := s.User(userID)
+		if err != nil {
+			continue
+		}
+		actOnRaider(s, guildID, user, cfg)
+	}
+}
+
+// actOnRaider kicks or bans a raid joiner if their account is young enough.
+func actOnRaider(s *discordgo.Session, guildID string, user *discordgo.User, cfg *config.AntiRaidConfig) {
+	if cfg.Action != "kick" && cfg.Action != "ban" {
+		return
+	}
+	maxAge := time.Duration(cfg.MaxAccountAgeDays) * 24 * time.Hour
+	if time.Since(snowflakeTime(user.ID)) > maxAge {
+		return
+	}
+
+	reason := lang.T("raid_action_reason")
+	var err error
+	action := "Kick (Anti-Raid)"
+	if cfg.Action == "ban" {
+		action = "Ban (Anti-Raid)"
+		err = s.GuildBanCreateWithReason(guildID, user.ID, reason, 1)
+	} else {
+		err = s.GuildMemberDeleteWithReason(guildID, user.ID, reason)
+	}
+	if err != nil {
+		log.Printf("[AntiRaid] Failed to %s %s: %v", cfg.Action, user.ID, err)
+		return
+	}
+	logModAction(s, guildID, action, user, s.State.User, reason, "")
+}
+
+func sendRaidAlert(s *discordgo.Session, guildID string, joins, locked int, raisedLevel bool, cfg *config.AntiRaidConfig) {
+	logCh := config.EffectiveModLogChannel(storage.Cfg, storage.GetGuild(guildID))
+	if logCh == "" {
+		return
+	}
+
+	level := lang.T("raid_level_unchanged")
+	if raisedLevel {
+		level = strconv.Itoa(cfg.VerificationLevel)
+	}
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: lang.T("raid_alert_title"),
+			Description: lang.T("raid_alert_desc",
+				"joins", strconv.Itoa(joins),
+				"window", strconv.Itoa(cfg.JoinWindowSeconds),
+				"locked", strconv.Itoa(locked),
+				"level", level,
+				"action", cfg.Action,
+			),
+			Color:     0xED4245,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}},
+	}
+	if cfg.AlertRole != "" {
+		msg.Content = "<@&" + cfg.AlertRole + ">"
+		msg.AllowedMentions = &discordgo.MessageAllowedMentions{Roles: []string{cfg.AlertRole}}
+	}
+	if _, err := s.ChannelMessageSendComplex(logCh, msg); err != nil {
+		log.Printf("[AntiRaid] Failed to send raid alert: %v", err)
+	}
+}
+
+// endRaid unlocks the channels raid mode locked and restores the previous
+// verification level. It returns false when no raid was active.
+func endRaid(s *discordgo.Session, guildID string, by *discordgo.User) bool {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	raid := gs.Raid
+	if !raid.Active {
+		gs.Unlock()
+		return false
+	}
+	gs.Raid = config.RaidState{PreviousVerificationLevel: -1}
+	gs.Unlock()
+	_ = gs.Save()
+
+	for _, chID := range raid.LockedChannels {
+		if err := unlockChannel(s, guildID, chID, lockHolderRaid); err != nil {
+			log.Printf("[AntiRaid] Failed to unlock channel %s: %v", chID, err)
+		}
+	}
+	if raid.PreviousVerificationLevel >= 0 {
+		level := discordgo.VerificationLevel(raid.PreviousVerificationLevel)
+		if _, err := s.GuildEdit(guildID, &discordgo.GuildParams{VerificationLevel: &level}); err != nil {
+			log.Printf("[AntiRaid] Failed to restore verification level: %v", err)
+		}
+	}
+
+	raidJoinsMu.Lock()
+	delete(raidJoins, guildID)
+	raidJoinsMu.Unlock()
+
+	logModEvent(s, guildID, lang.T("raid_ended_title"),
+		lang.T("raid_ended_log", "mod_id", by.ID, "joiners", strconv.Itoa(len(raid.JoinerIDs))),
+		0x57F287)
+	return true
+}
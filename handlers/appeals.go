@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func appealCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "appeals",
+			Description:              "Let banned and muted members appeal their punishment",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "channel",
+					Description: "Enable appeals and send them to this staff channel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Staff channel for appeals", Required: true, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+					},
+				},
+				{
+					Name:        "disable",
+					Description: "Stop offering appeals",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "status",
+					Description: "Show the appeal settings",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+func handleAppealsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	gs := storage.GetGuild(i.GuildID)
+
+	switch sub.Name {
+	case "channel":
+		ch := subOptMap(sub.Options)["channel"].ChannelValue(s)
+		gs.Lock()
+		gs.Appeals.Enabled = true
+		gs.Appeals.ChannelID = ch.ID
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("appeal_enabled", "channel_id", ch.ID), true)
+
+	case "disable":
+		gs.Lock()
+		gs.Appeals.Enabled = false
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("appeal_disabled"), true)
+
+	case "status":
+		gs.Lock()
+		a := gs.Appeals
+		gs.Unlock()
+		if !a.Enabled || a.ChannelID == "" {
+			respond(s, i, lang.T("appeal_status_off"), true)
+			return
+		}
+		respond(s, i, lang.T("appeal_status_on", "channel_id", a.ChannelID), true)
+	}
+}
+
+// handleDMInteraction routes the only interactions the bot accepts outside a
+// guild: the appeal button and modal on punishment DMs.
+func handleDMInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		if strings.HasPrefix(i.MessageComponentData().CustomID, "appeal:") {
+			HandleAppealButton(s, i)
+		}
+	case discordgo.InteractionModalSubmit:
+		if strings.HasPrefix(i.ModalSubmitData().CustomID, "appeal_modal:") {
+			HandleAppealModal(s, i)
+		}
+	}
+}
+
+// parseAppealID splits "<prefix>:<guildID>:<caseID>".
+func parseAppealID(customID string) (string, int, bool) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 {
+		return "", 0, false
+	}
+	caseID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[1], caseID, true
+}
+
+// interactionUser returns the user behind an interaction in a guild or a DM.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// appealComponents returns the Appeal button for a case DM, or nil when the
+// guild doesn't take appeals.
+func appealComponents(guildID string, caseID int) []discordgo.MessageComponent {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	enabled := gs.Appeals.Enabled && gs.Appeals.ChannelID != ""
+	gs.Unlock()
+	if !enabled {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    lang.T("appeal_button"),
+				Style:    discordgo.PrimaryButton,
+				CustomID: fmt.Sprintf("appeal:%s:%d", guildID, caseID),
+			},
+		}},
+	}
+}
+
+// appealableCase loads a case and checks that user may appeal it.
+func appealableCase(guildID string, caseID int, userID string) (*storage.ModCase, string) {
+	if storage.DB == nil {
+		return nil, "appeal_unavailable"
+	}
+	c, err := storage.DB.GetModCase(guildID, caseID)
+	if err != nil || c == nil || c.UserID != userID {
+		return nil, "appeal_unavailable"
+	}
+	if c.AppealStatus != "" {
+		return nil, "appeal_already"
+	}
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	enabled := gs.Appeals.Enabled && gs.Appeals.ChannelID != ""
+	gs.Unlock()
+	if !enabled {
+		return nil, "appeal_closed"
+	}
+	return c, ""
+}
+
+// HandleAppealButton opens the appeal modal from a punishment DM.
+func HandleAppealButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID, caseID, ok := parseAppealID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	if _, problem := appealableCase(guildID, caseID, interactionUser(i).ID); problem != "" {
+		respond(s, i, lang.T(problem), true)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("appeal_modal:%s:%d", guildID, caseID),
+			Title:    lang.T("appeal_modal_title", "id", strconv.Itoa(caseID)),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "appeal",
+						Label:     lang.T("appeal_modal_label"),
+						Style:     discordgo.TextInputParagraph,
+						Required:  true,
+						MinLength: 10,
+						MaxLength: 1000,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[Appeals] Failed to open modal for case #%d: %v", caseID, err)
+	}
+}
+
+// HandleAppealModal files an appeal and posts it to the staff channel.
+func HandleAppealModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	guildID, caseID, ok := parseAppealID(data.CustomID)
+	if !ok {
+		return
+	}
+	user := interactionUser(i)
+	c, problem := appealableCase(guildID, caseID, user.ID)
+	if problem != "" {
+		respond(s, i, lang.T(problem), true)
+		return
+	}
+	text := strings.TrimSpace(modalValue(data, "appeal"))
+
+	if ok, err := storage.DB.SetModCaseAppeal(guildID, caseID, "", "pending", text, ""); err != nil || !ok {
+		respond(s, i, lang.T("appeal_unavailable"), true)
+		return
+	}
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	channelID := gs.Appeals.ChannelID
+	gs.Unlock()
+
+	embed := appealEmbed(c, user, text)
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: lang.T("appeal_accept_button"), Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("appeal_accept:%s:%d", guildID, caseID)},
+				discordgo.Button{Label: lang.T("appeal_deny_button"), Style: discordgo.DangerButton, CustomID: fmt.Sprintf("appeal_deny:%s:%d", guildID, caseID)},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("[Appeals] Failed to post appeal for case #%d: %v", caseID, err)
+		_, _ = storage.DB.SetModCaseAppeal(guildID, caseID, "pending", "", "", "")
+		respond(s, i, lang.T("appeal_unavailable"), true)
+		return
+	}
+
+	// Drop the button so the same case can't be appealed twice.
+	if i.Message != nil {
+		_, _ = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    i.Message.ChannelID,
+			ID:         i.Message.ID,
+			Components: &[]discordgo.MessageComponent{},
+		})
+	}
+	respond(s, i, lang.T("appeal_submitted", "id", strconv.Itoa(caseID)), false)
+}
+
+func appealEmbed(c *storage.ModCase, user *discordgo.User, text string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: lang.T("appeal_staff_title", "id", strconv.Itoa(c.ID), "action", c.Action),
+		Color: 0xFEE75C,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: lang.T("modlog_user_field"), Value: fmt.Sprintf("%s (`%s`)", user.Username, user.ID), Inline: true},
+			{Name: lang.T("modlog_mod_field"), Value: "<@" + c.ModID + ">", Inline: true},
+			{Name: lang.T("modlog_reason_field"), Value: c.Reason},
+			{Name: lang.T("appeal_text_field"), Value: text},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if c.Duration != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: lang.T("modlog_duration_field"), Value: c.Duration, Inline: true})
+	}
+	return embed
+}
+
+// HandleAppealDecision handles the Accept and Deny buttons on a staff appeal.
+// Accepting lifts the ban or mute; with ban sync enabled the unban also
+// pardons the linked Minecraft player through the bridge's ban-remove handler.
+func HandleAppealDecision(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	guildID, caseID, ok := parseAppealID(customID)
+	if !ok || guildID != i.GuildID || storage.DB == nil {
+		return
+	}
+	accepted := strings.HasPrefix(customID, "appeal_accept:")
+
+	c, err := storage.DB.GetModCase(guildID, caseID)
+	if err != nil || c == nil {
+		respond(s, i, lang.T("appeal_unavailable"), true)
+		return
+	}
+	mod := i.Member.User
+	status := "denied"
+	if accepted {
+		status = "accepted"
+	}
+
+	// Claim the decision before acting so two moderators can't both decide.
+	claimed, err := storage.DB.SetModCaseAppeal(guildID, caseID, "pending", status, c.AppealText, mod.ID)
+	if err != nil {
+		log.Printf("[Appeals] Failed to record decision on case #%d: %v", caseID, err)
+		respond(s, i, lang.T("appeal_unavailable"), true)
+		return
+	}
+	if !claimed {
+		if fresh, err := storage.DB.GetModCase(guildID, caseID); err == nil && fresh != nil {
+			c = fresh
+		}
+		respond(s, i, lang.T("appeal_already_decided", "status", c.AppealStatus), true)
+		return
+	}
+	// release hands the appeal back to the queue when lifting the punishment fails.
+	release := func() {
+		if _, err := storage.DB.SetModCaseAppeal(guildID, caseID, status, "pending", c.AppealText, ""); err != nil {
+			log.Printf("[Appeals] Failed to reopen case #%d: %v", caseID, err)
+		}
+	}
+
+	target, err := s.User(c.UserID)
+	if err != nil {
+		target = &discordgo.User{ID: c.UserID, Username: c.UserID}
+	}
+
+	if accepted {
+		reason := lang.T("appeal_lift_reason", "id", strconv.Itoa(caseID))
+		switch c.Action {
+		case "Ban":
+			if err := s.GuildBanDelete(guildID, c.UserID); err != nil {
+				release()
+				respond(s, i, lang.T("mod_unban_failed", "error", err.Error()), true)
+				return
+			}
+			logModAction(s, guildID, "Unban", target, mod, reason, "")
+		case "Mute":
+			if err := s.GuildMemberTimeout(guildID, c.UserID, nil); err != nil {
+				release()
+				respond(s, i, lang.T("mod_unmute_failed", "error", err.Error()), true)
+				return
+			}
+			logModAction(s, guildID, "Unmute", target, mod, reason, "")
+			if ActiveBridge != nil {
+				ActiveBridge.SyncUnmuteToMC(c.UserID)
+			}
+		}
+	}
+
+	guildName := guildID
+	if g, err := s.State.Guild(guildID); err == nil {
+		guildName = g.Name
+	}
+	if ch, err := s.UserChannelCreate(c.UserID); err == nil {
+		_, _ = s.ChannelMessageSend(ch.ID, lang.T("appeal_dm_"+status, "id", strconv.Itoa(caseID), "server", guildName))
+	}
+
+	embed := appealEmbed(c, target, c.AppealText)
+	embed.Color = 0xED4245
+	if accepted {
+		embed.Color = 0x57F287
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  lang.T("appeal_decision_field"),
+		Value: lang.T("appeal_decision_"+status, "mod_id", mod.ID),
+	})
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
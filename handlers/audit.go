@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Audit event types, as used in /audit toggle and AuditState.Disabled.
+const (
+	auditMemberRoles = "member_roles"
+	auditNicknames   = "nicknames"
+	auditVoice       = "voice"
+	auditChannels    = "channels"
+	auditRoles       = "roles"
+)
+
+var auditEvents = []string{auditMemberRoles, auditNicknames, auditVoice, auditChannels, auditRoles}
+
+func auditCommands() []*discordgo.ApplicationCommand {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(auditEvents))
+	for _, ev := range auditEvents {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: ev, Value: ev})
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "audit",
+			Description:              "Log server activity to an audit channel",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "channel",
+					Description: "Send the audit feed to this channel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Audit channel", Required: true, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+					},
+				},
+				{
+					Name:        "toggle",
+					Description: "Turn one type of event on or off",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "event", Description: "Event type", Required: true, Choices: choices},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Log this event type", Required: true},
+					},
+				},
+				{
+					Name:        "disable",
+					Description: "Stop the audit feed",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "status",
+					Description: "Show the audit channel and which events are logged",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+func handleAuditCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	om := subOptMap(sub.Options)
+	gs := storage.GetGuild(i.GuildID)
+
+	switch sub.Name {
+	case "channel":
+		ch := om["channel"].ChannelValue(s)
+		gs.Lock()
+		gs.Audit.ChannelID = ch.ID
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("audit_channel_set", "channel_id", ch.ID), true)
+
+	case "toggle":
+		event := om["event"].StringValue()
+		enabled := om["enabled"].BoolValue()
+		gs.Lock()
+		disabled := make([]string, 0, len(gs.Audit.Disabled)+1)
+		for _, ev := range gs.Audit.Disabled {
+			if ev != event {
+				disabled = append(disabled, ev)
+			}
+		}
+		if !enabled {
+			disabled = append(disabled, event)
+		}
+		gs.Audit.Disabled = disabled
+		gs.Unlock()
+		_ = gs.Save()
+		key := "audit_event_off"
+		if enabled {
+			key = "audit_event_on"
+		}
+		respond(s, i, lang.T(key, "event", event), true)
+
+	case "disable":
+		gs.Lock()
+		gs.Audit.ChannelID = ""
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("audit_disabled"), true)
+
+	case "status":
+		gs.Lock()
+		a := gs.Audit
+		gs.Unlock()
+		if a.ChannelID == "" {
+			respond(s, i, lang.T("audit_status_off"), true)
+			return
+		}
+		off := make(map[string]bool, len(a.Disabled))
+		for _, ev := range a.Disabled {
+			off[ev] = true
+		}
+		var lines []string
+		for _, ev := range auditEvents {
+			mark := "✅"
+			if off[ev] {
+				mark = "❌"
+			}
+			lines = append(lines, mark+" `"+ev+"`")
+		}
+		respond(s, i, lang.T("audit_status", "channel_id", a.ChannelID, "events", strings.Join(lines, "\n")), true)
+	}
+}
+
+// RegisterAudit logs member, voice, channel and role activity to each guild's
+// audit channel. Events from the gateway carry the previous state only when
+// it was cached, so changes the cache missed are skipped.
+func RegisterAudit(s *discordgo.Session) {
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		auditMemberUpdate(s, m)
+	})
+	s.AddHandler(func(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+		auditVoiceUpdate(s, v)
+	})
+	s.AddHandler(func(s *discordgo.Session, c *discordgo.ChannelCreate) {
+		if c.GuildID != "" {
+			postAudit(s, c.GuildID, auditChannels, 0x57F287,
+				lang.T("audit_channel_created", "channel_id", c.ID, "name", c.Name))
+		}
+	})
+	s.AddHandler(func(s *discordgo.Session, c *discordgo.ChannelDelete) {
+		if c.GuildID != "" {
+			postAudit(s, c.GuildID, auditChannels, 0xED4245,
+				lang.T("audit_channel_deleted", "name", c.Name))
+		}
+	})
+	s.AddHandler(func(s *discordgo.Session, r *discordgo.GuildRoleCreate) {
+		postAudit(s, r.GuildID, auditRoles, 0x57F287,
+			lang.T("audit_role_created", "role_id", r.Role.ID, "name", r.Role.Name))
+	})
+	s.AddHandler(func(s *discordgo.Session, r *discordgo.GuildRoleUpdate) {
+		auditRoleUpdate(s, r)
+	})
+	s.AddHandler(func(s *discordgo.Session, r *discordgo.GuildRoleDelete) {
+		name := r.RoleID
+		if r.BeforeDelete != nil {
+			name = r.BeforeDelete.Name
+		}
+		postAudit(s, r.GuildID, auditRoles, 0xED4245, lang.T("audit_role_deleted", "name", name))
+	})
+}
+
+// auditChannelFor returns the audit channel when event is logged in guildID.
+func auditChannelFor(guildID, event string) string {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	defer gs.Unlock()
+	if gs.Audit.ChannelID == "" {
+		return ""
+	}
+	for _, ev := range gs.Audit.Disabled {
+		if ev == event {
+			return ""
+		}
+	}
+	return gs.Audit.ChannelID
+}
+
+func postAudit(s *discordgo.Session, guildID, event string, color int, description string) {
+	channelID := auditChannelFor(guildID, event)
+	if channelID == "" {
+		return
+	}
+	_, err := s.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+		Description: description,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("[Audit] Failed to post %s event in %s: %v", event, guildID, err)
+	}
+}
+
+func auditMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	if m.BeforeUpdate == nil || m.Member == nil || m.User == nil {
+		return
+	}
+	before, after := m.BeforeUpdate, m.Member
+
+	if before.Nick != after.Nick {
+		postAudit(s, m.GuildID, auditNicknames, 0x5865F2, lang.T("audit_nickname",
+			"user_id", after.User.ID, "before", orDash(before.Nick), "after", orDash(after.Nick)))
+	}
+
+	added, removed := diffRoles(before.Roles, after.Roles)
+	if len(added) > 0 || len(removed) > 0 {
+		var parts []string
+		if len(added) > 0 {
+			parts = append(parts, lang.T("audit_roles_added", "roles", formatRoleMentions(added)))
+		}
+		if len(removed) > 0 {
+			parts = append(parts, lang.T("audit_roles_removed", "roles", formatRoleMentions(removed)))
+		}
+		postAudit(s, m.GuildID, auditMemberRoles, 0x5865F2, lang.T("audit_member_roles",
+			"user_id", after.User.ID, "changes", strings.Join(parts, "\n")))
+	}
+}
+
+// diffRoles returns the roles in after but not before, and vice versa.
+func diffRoles(before, after []string) (added, removed []string) {
+	had := make(map[string]bool, len(before))
+	for _, id := range before {
+		had[id] = true
+	}
+	has := make(map[string]bool, len(after))
+	for _, id := range after {
+		has[id] = true
+		if !had[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !has[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+func auditVoiceUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if v.VoiceState == nil || v.GuildID == "" {
+		return
+	}
+	from := ""
+	if v.BeforeUpdate != nil {
+		from = v.BeforeUpdate.ChannelID
+	}
+	to := v.ChannelID
+	if from == to {
+		return // mute, deafen, stream or video changes
+	}
+
+	switch {
+	case from == "":
+		postAudit(s, v.GuildID, auditVoice, 0x57F287, lang.T("audit_voice_join", "user_id", v.UserID, "channel_id", to))
+	case to == "":
+		postAudit(s, v.GuildID, auditVoice, 0xED4245, lang.T("audit_voice_leave", "user_id", v.UserID, "channel_id", from))
+	default:
+		postAudit(s, v.GuildID, auditVoice, 0xFEE75C, lang.T("audit_voice_move", "user_id", v.UserID, "from", from, "to", to))
+	}
+}
+
+func auditRoleUpdate(s *discordgo.Session, r *discordgo.GuildRoleUpdate) {
+	if r.BeforeUpdate == nil || r.Role == nil {
+		return
+	}
+	before, after := r.BeforeUpdate, r.Role
+
+	var changes []string
+	if before.Name != after.Name {
+		changes = append(changes, lang.T("audit_role_name", "before", before.Name, "after", after.Name))
+	}
+	if before.Color != after.Color {
+		changes = append(changes, lang.T("audit_role_color", "before", fmt.Sprintf("#%06X", before.Color), "after", fmt.Sprintf("#%06X", after.Color)))
+	}
+	if before.Permissions != after.Permissions {
+		changes = append(changes, lang.T("audit_role_permissions"))
+	}
+	if before.Hoist != after.Hoist {
+		changes = append(changes, lang.T("audit_role_hoist", "value", fmt.Sprint(after.Hoist)))
+	}
+	if before.Mentionable != after.Mentionable {
+		changes = append(changes, lang.T("audit_role_mentionable", "value", fmt.Sprint(after.Mentionable)))
+	}
+	if len(changes) == 0 {
+		return // position shifts caused by other roles moving
+	}
+	postAudit(s, r.GuildID, auditRoles, 0xFEE75C, lang.T("audit_role_updated",
+		"role_id", after.ID, "changes", strings.Join(changes, "\n")))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
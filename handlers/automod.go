@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"discord-bot/lang"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// applyAutoModAction carries out an automatic moderation action against user.
+// Everything except "log" removes the offending message (when there is one);
+// warnings and timeouts go through warnMember and timeoutMember, so the member
+// is DMed and a mod case is recorded just like /warn and /mute.
+func applyAutoModAction(s *discordgo.Session, guildID, channelID, messageID string, user *discordgo.User, action, duration, reason string) {
+	bot := s.State.User
+
+	if action != "log" && messageID != "" {
+		forgetGhostPing(messageID)
+		if err := s.ChannelMessageDelete(channelID, messageID); err != nil {
+			log.Printf("[AutoMod] Failed to delete message %s: %v", messageID, err)
+		}
+	}
+
+	switch action {
+	case "warn":
+		warnMember(s, guildID, user, bot, reason, false)
+		if channelID != "" {
+			sendTemp(s, channelID, lang.T("automod_warned", "user_id", user.ID, "reason", reason), 8)
+		}
+
+	case "timeout":
+		dur, err := parseDuration(duration)
+		if err != nil || dur <= 0 {
+			duration, dur = "10m", 10*time.Minute
+		}
+		if err := timeoutMember(s, guildID, user, bot, dur, duration, reason, false); err != nil {
+			log.Printf("[AutoMod] Failed to time out %s: %v", user.ID, err)
+			return
+		}
+		if channelID != "" {
+			sendTemp(s, channelID, lang.T("automod_timed_out", "user_id", user.ID, "duration", duration, "reason", reason), 8)
+		}
+
+	case "delete":
+		logModEvent(s, guildID, lang.T("automod_log_title"),
+			lang.T("automod_log_deleted", "user_id", user.ID, "channel_id", channelID, "reason", reason), 0xFEE75C)
+		if channelID != "" {
+			sendTemp(s, channelID, lang.T("automod_deleted", "user_id", user.ID), 6)
+		}
+
+	default:
+		logModEvent(s, guildID, lang.T("automod_log_title"),
+			lang.T("automod_log_flagged", "user_id", user.ID, "channel_id", channelID, "reason", reason), 0x5865F2)
+	}
+}
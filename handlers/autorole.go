@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"discord-bot/config"
 	"discord-bot/lang"
@@ -12,21 +16,60 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var (
+	joinRoleTimers   = make(map[string]*time.Timer)
+	joinRoleTimersMu sync.Mutex
+)
+
 func autoroleCommands() []*discordgo.ApplicationCommand {
 	return []*discordgo.ApplicationCommand{
 		{
 			Name:                     "joinrole",
-			Description:              "Configure the role automatically given to new members",
+			Description:              "Configure the roles automatically given to new members",
 			DefaultMemberPermissions: &adminPerm,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Name:        "set",
-					Description: "Set the role given on join",
+					Description: "Replace the member join roles with a single role",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Options: []*discordgo.ApplicationCommandOption{
 						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to assign when someone joins", Required: true},
 					},
 				},
+				{
+					Name:        "add",
+					Description: "Add a role given on join",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to assign when someone joins", Required: true},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "bots", Description: "Give this role to bots instead of members"},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Stop giving a role on join",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to stop assigning", Required: true},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "bots", Description: "Remove it from the bot join roles"},
+					},
+				},
+				{
+					Name:        "delay",
+					Description: "Wait before giving join roles to members",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Delay (e.g. 10m, 1h) or 0 to assign immediately", Required: true},
+					},
+				},
+				{
+					Name:        "screening",
+					Description: "Wait until members pass membership screening before giving join roles",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Wait for screening", Required: true},
+					},
+				},
 				{
 					Name:        "disable",
 					Description: "Disable auto-role on join",
@@ -39,7 +82,7 @@ func autoroleCommands() []*discordgo.ApplicationCommand {
 				},
 				{
 					Name:        "check",
-					Description: "Check if the bot can assign the auto-role (permission check)",
+					Description: "Check if the bot can assign every join role (permission check)",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 				},
 			},
@@ -67,6 +110,52 @@ func autoroleCommands() []*discordgo.ApplicationCommand {
 						{Type: discordgo.ApplicationCommandOptionString, Name: "menu_id", Description: "Menu ID (from /rolemenu list)", Required: true},
 						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to add", Required: true},
 						{Type: discordgo.ApplicationCommandOptionString, Name: "label", Description: "Button label — include emoji here if you want (e.g. 🇫🇷 Français)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "requires", Description: "Role the member must already have to pick this one"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "group", Description: "Exclusion group — members can only hold one role per group, across all menus"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Remove the role automatically after this long (e.g. 12h, 7d)"},
+					},
+				},
+				{
+					Name:        "rules",
+					Description: "Change the prerequisite, exclusion group or expiry of a role in a menu",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "menu_id", Description: "Menu ID (from /rolemenu list)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role in the menu", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "requires", Description: "Role the member must already have"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "group", Description: "Exclusion group name"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Expire the role after this long (e.g. 12h, 7d)"},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "clear", Description: "Remove the prerequisite, group and duration"},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Remove a role button from a menu",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "menu_id", Description: "Menu ID (from /rolemenu list)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to remove", Required: true},
+					},
+				},
+				{
+					Name:        "edit",
+					Description: "Change the title, description or mode of a menu",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "menu_id", Description: "Menu ID (from /rolemenu list)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "title", Description: "New menu title"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "description", Description: "New menu description"},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "single", Description: "Only one role at a time"},
+					},
+				},
+				{
+					Name:        "move",
+					Description: "Change the position of a role button in a menu",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "menu_id", Description: "Menu ID (from /rolemenu list)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to move", Required: true},
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "position", Description: "New position (1 = first button)", Required: true},
 					},
 				},
 				{
@@ -116,46 +205,187 @@ func handleJoinRoleCommand(s *discordgo.Session, i *discordgo.InteractionCreate)
 		}
 
 		gs.Lock()
-		gs.AutoRole = config.AutoRoleState{Enabled: true, RoleID: role.ID}
+		gs.AutoRole.Enabled = true
+		gs.AutoRole.RoleIDs = []string{role.ID}
 		gs.Unlock()
 		_ = gs.Save()
 
-	case "disable":
+	case "add":
+		om := subOptMap(sub.Options)
+		role := om["role"].RoleValue(s, i.GuildID)
+		bots := om["bots"] != nil && om["bots"].BoolValue()
+
 		gs.Lock()
-		gs.AutoRole = config.AutoRoleState{Enabled: false}
+		list := &gs.AutoRole.RoleIDs
+		if bots {
+			list = &gs.AutoRole.BotRoleIDs
+		}
+		exists := false
+		for _, rid := range *list {
+			if rid == role.ID {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			*list = append(*list, role.ID)
+		}
+		gs.AutoRole.Enabled = true
 		gs.Unlock()
 		_ = gs.Save()
-		respond(s, i, lang.T("autorole_disabled"), true)
 
-	case "status":
+		target := joinRoleTargetLabel(bots)
+		if warning := checkBotRoleHierarchy(s, i.GuildID, role); warning != "" {
+			respond(s, i, lang.T("autorole_add_warning", "role_id", role.ID, "target", target, "warning", warning), true)
+		} else {
+			respond(s, i, lang.T("autorole_added", "role_id", role.ID, "target", target), true)
+		}
+
+	case "remove":
+		om := subOptMap(sub.Options)
+		role := om["role"].RoleValue(s, i.GuildID)
+		bots := om["bots"] != nil && om["bots"].BoolValue()
+
 		gs.Lock()
-		ar := gs.AutoRole
+		list := &gs.AutoRole.RoleIDs
+		if bots {
+			list = &gs.AutoRole.BotRoleIDs
+		}
+		found := false
+		kept := make([]string, 0, len(*list))
+		for _, rid := range *list {
+			if rid == role.ID {
+				found = true
+				continue
+			}
+			kept = append(kept, rid)
+		}
+		*list = kept
 		gs.Unlock()
-		if ar.Enabled && ar.RoleID != "" {
-			respond(s, i, lang.T("autorole_status_enabled", "role_id", ar.RoleID), true)
+
+		target := joinRoleTargetLabel(bots)
+		if !found {
+			respond(s, i, lang.T("autorole_not_listed", "role_id", role.ID, "target", target), true)
+			return
+		}
+		_ = gs.Save()
+		respond(s, i, lang.T("autorole_removed", "role_id", role.ID, "target", target), true)
+
+	case "delay":
+		durStr := strings.TrimSpace(subOptMap(sub.Options)["duration"].StringValue())
+		if durStr == "0" || durStr == "" {
+			durStr = ""
+		} else if dur, err := parseDuration(durStr); err != nil || dur <= 0 {
+			respond(s, i, lang.T("autorole_invalid_delay"), true)
+			return
+		}
+
+		gs.Lock()
+		gs.AutoRole.Delay = durStr
+		gs.Unlock()
+		_ = gs.Save()
+
+		if durStr == "" {
+			respond(s, i, lang.T("autorole_delay_cleared"), true)
 		} else {
-			respond(s, i, lang.T("autorole_status_disabled"), true)
+			respond(s, i, lang.T("autorole_delay_set", "delay", durStr), true)
 		}
 
-	case "check":
+	case "screening":
+		enabled := subOptMap(sub.Options)["enabled"].BoolValue()
 		gs.Lock()
-		ar := gs.AutoRole
+		gs.AutoRole.WaitForScreening = enabled
 		gs.Unlock()
-		if !ar.Enabled || ar.RoleID == "" {
-			respond(s, i, lang.T("autorole_hint_set"), true)
+		_ = gs.Save()
+
+		if enabled {
+			respond(s, i, lang.T("autorole_screening_on"), true)
+		} else {
+			respond(s, i, lang.T("autorole_screening_off"), true)
+		}
+
+	case "disable":
+		gs.Lock()
+		gs.AutoRole.Enabled = false
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("autorole_disabled"), true)
+
+	case "status":
+		ar := joinRoleConfig(gs)
+		if !ar.Enabled || (len(ar.RoleIDs) == 0 && len(ar.BotRoleIDs) == 0) {
+			respond(s, i, lang.T("autorole_status_disabled"), true)
 			return
 		}
-		role, err := s.State.Role(i.GuildID, ar.RoleID)
-		if err != nil {
-			respond(s, i, lang.T("autorole_role_deleted", "role_id", ar.RoleID), true)
+		delay := ar.Delay
+		if delay == "" {
+			delay = lang.T("autorole_delay_none")
+		}
+		screening := lang.T("autorole_screening_no")
+		if ar.WaitForScreening {
+			screening = lang.T("autorole_screening_yes")
+		}
+		respond(s, i, lang.T("autorole_status_enabled",
+			"roles", formatRoleMentions(ar.RoleIDs),
+			"bot_roles", formatRoleMentions(ar.BotRoleIDs),
+			"delay", delay,
+			"screening", screening,
+		), true)
+
+	case "check":
+		ar := joinRoleConfig(gs)
+		if !ar.Enabled || (len(ar.RoleIDs) == 0 && len(ar.BotRoleIDs) == 0) {
+			respond(s, i, lang.T("autorole_hint_set"), true)
 			return
 		}
-		if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
-			respond(s, i, lang.T("autorole_problem", "warning", w), true)
-		} else {
-			respond(s, i, lang.T("autorole_ok", "role_id", ar.RoleID), true)
+
+		var sb strings.Builder
+		sb.WriteString(lang.T("autorole_check_header"))
+		problems := 0
+		check := func(roleID string, bots bool) {
+			target := joinRoleTargetLabel(bots)
+			role, err := s.State.Role(i.GuildID, roleID)
+			if err != nil {
+				problems++
+				sb.WriteString(lang.T("autorole_check_deleted", "role_id", roleID, "target", target))
+				return
+			}
+			if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
+				problems++
+				sb.WriteString(lang.T("autorole_check_problem", "role_id", roleID, "target", target, "warning", w))
+				return
+			}
+			sb.WriteString(lang.T("autorole_check_ok", "role_id", roleID, "target", target))
 		}
+		for _, rid := range ar.RoleIDs {
+			check(rid, false)
+		}
+		for _, rid := range ar.BotRoleIDs {
+			check(rid, true)
+		}
+		if problems == 0 {
+			sb.WriteString(lang.T("autorole_check_all_ok"))
+		}
+		respond(s, i, sb.String(), true)
+	}
+}
+
+// joinRoleConfig returns a copy of the guild's join role settings that is safe
+// to use without holding the lock.
+func joinRoleConfig(gs *config.GuildState) config.AutoRoleState {
+	gs.Lock()
+	defer gs.Unlock()
+	ar := gs.AutoRole
+	ar.RoleIDs = append([]string(nil), ar.RoleIDs...)
+	ar.BotRoleIDs = append([]string(nil), ar.BotRoleIDs...)
+	return ar
+}
+
+func joinRoleTargetLabel(bots bool) string {
+	if bots {
+		return lang.T("autorole_target_bots")
 	}
+	return lang.T("autorole_target_members")
 }
 
 func checkBotRoleHierarchy(s *discordgo.Session, guildID string, targetRole *discordgo.Role) string {
@@ -194,22 +424,192 @@ func checkBotRoleHierarchy(s *discordgo.Session, guildID string, targetRole *dis
 	return ""
 }
 
-func AssignJoinRole(s *discordgo.Session, guildID, userID string) {
+// AssignJoinRole gives a new member their join roles. Bots get the bot roles
+// straight away; members may be held back until screening completes and/or the
+// configured delay has passed.
+func AssignJoinRole(s *discordgo.Session, guildID string, member *discordgo.Member) {
+	ar := joinRoleConfig(storage.GetGuild(guildID))
+	if !ar.Enabled || member.User == nil {
+		return
+	}
+
+	if member.User.Bot {
+		giveJoinRoles(s, guildID, member.User.ID, ar.BotRoleIDs)
+		return
+	}
+	if len(ar.RoleIDs) == 0 {
+		return
+	}
+
+	if ar.WaitForScreening && member.Pending {
+		addPendingJoinRole(guildID, config.PendingJoinRole{UserID: member.User.ID, AwaitScreening: true})
+		log.Printf("[AutoRole] Waiting for %s to complete membership screening in guild %s", member.User.ID, guildID)
+		return
+	}
+	queueJoinRoles(s, guildID, member.User.ID, ar)
+}
+
+// handleJoinRoleScreening releases join roles held back for a member once
+// Discord reports they have passed membership screening.
+func handleJoinRoleScreening(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	if m.Member == nil || m.User == nil || m.Pending {
+		return
+	}
+
+	gs := storage.GetGuild(m.GuildID)
+	gs.Lock()
+	waiting := false
+	for _, p := range gs.PendingJoinRoles {
+		if p.UserID == m.User.ID && p.AwaitScreening {
+			waiting = true
+			break
+		}
+	}
+	gs.Unlock()
+	if !waiting {
+		return
+	}
+
+	removePendingJoinRole(m.GuildID, m.User.ID)
+	ar := joinRoleConfig(gs)
+	if !ar.Enabled {
+		return
+	}
+	queueJoinRoles(s, m.GuildID, m.User.ID, ar)
+}
+
+// queueJoinRoles gives the member join roles now, or schedules them when a
+// delay is configured.
+func queueJoinRoles(s *discordgo.Session, guildID, userID string, ar config.AutoRoleState) {
+	var dur time.Duration
+	if ar.Delay != "" {
+		dur, _ = parseDuration(ar.Delay)
+	}
+	if dur <= 0 {
+		giveJoinRoles(s, guildID, userID, ar.RoleIDs)
+		return
+	}
+
+	addPendingJoinRole(guildID, config.PendingJoinRole{
+		UserID:   userID,
+		AssignAt: time.Now().Add(dur).Format(time.RFC3339),
+	})
+	scheduleJoinRoles(s, guildID, userID, dur)
+}
+
+func giveJoinRoles(s *discordgo.Session, guildID, userID string, roleIDs []string) {
+	for _, rid := range roleIDs {
+		if err := s.GuildMemberRoleAdd(guildID, userID, rid); err != nil {
+			log.Printf("[AutoRole] FAILED to assign role %s to user %s in guild %s: %v",
+				rid, userID, guildID, err)
+			log.Printf("[AutoRole] TIP: Make sure the bot's role is ABOVE <@&%s> in the role list, and the bot has 'Manage Roles' permission.", rid)
+		} else {
+			log.Printf("[AutoRole] Assigned role %s to user %s in guild %s", rid, userID, guildID)
+		}
+	}
+}
+
+func addPendingJoinRole(guildID string, p config.PendingJoinRole) {
 	gs := storage.GetGuild(guildID)
 	gs.Lock()
-	ar := gs.AutoRole
+	replaced := false
+	for idx := range gs.PendingJoinRoles {
+		if gs.PendingJoinRoles[idx].UserID == p.UserID {
+			gs.PendingJoinRoles[idx] = p
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		gs.PendingJoinRoles = append(gs.PendingJoinRoles, p)
+	}
+	gs.Unlock()
+	_ = gs.Save()
+}
+
+func removePendingJoinRole(guildID, userID string) bool {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	found := false
+	kept := make([]config.PendingJoinRole, 0, len(gs.PendingJoinRoles))
+	for _, p := range gs.PendingJoinRoles {
+		if p.UserID == userID {
+			found = true
+			continue
+		}
+		kept = append(kept, p)
+	}
+	gs.PendingJoinRoles = kept
 	gs.Unlock()
+	if found {
+		_ = gs.Save()
+	}
+	return found
+}
 
-	if !ar.Enabled || ar.RoleID == "" {
+func scheduleJoinRoles(s *discordgo.Session, guildID, userID string, dur time.Duration) {
+	key := guildID + ":" + userID
+	t := time.AfterFunc(dur, func() {
+		fireJoinRoles(s, guildID, userID)
+	})
+	joinRoleTimersMu.Lock()
+	if old, ok := joinRoleTimers[key]; ok {
+		old.Stop()
+	}
+	joinRoleTimers[key] = t
+	joinRoleTimersMu.Unlock()
+}
+
+// cancelPendingJoinRole forgets a held-back member, e.g. when they leave.
+func cancelPendingJoinRole(guildID, userID string) {
+	key := guildID + ":" + userID
+	joinRoleTimersMu.Lock()
+	if t, ok := joinRoleTimers[key]; ok {
+		t.Stop()
+		delete(joinRoleTimers, key)
+	}
+	joinRoleTimersMu.Unlock()
+	removePendingJoinRole(guildID, userID)
+}
+
+func fireJoinRoles(s *discordgo.Session, guildID, userID string) {
+	joinRoleTimersMu.Lock()
+	delete(joinRoleTimers, guildID+":"+userID)
+	joinRoleTimersMu.Unlock()
+
+	if !removePendingJoinRole(guildID, userID) {
 		return
 	}
+	ar := joinRoleConfig(storage.GetGuild(guildID))
+	if !ar.Enabled {
+		return
+	}
+	giveJoinRoles(s, guildID, userID, ar.RoleIDs)
+}
 
-	if err := s.GuildMemberRoleAdd(guildID, userID, ar.RoleID); err != nil {
-		log.Printf("[AutoRole] FAILED to assign role %s to user %s in guild %s: %v",
-			ar.RoleID, userID, guildID, err)
-		log.Printf("[AutoRole] TIP: Make sure the bot's role is ABOVE <@&%s> in the role list, and the bot has 'Manage Roles' permission.", ar.RoleID)
-	} else {
-		log.Printf("[AutoRole] Assigned role %s to user %s in guild %s", ar.RoleID, userID, guildID)
+// RestoreJoinRoleTimers reschedules delayed join roles after a restart. Members
+// still waiting on screening are left until their next member update.
+func RestoreJoinRoleTimers(s *discordgo.Session, gs *config.GuildState) {
+	gs.Lock()
+	guildID := gs.GuildID
+	pending := make([]config.PendingJoinRole, len(gs.PendingJoinRoles))
+	copy(pending, gs.PendingJoinRoles)
+	gs.Unlock()
+
+	for _, p := range pending {
+		if p.AssignAt == "" {
+			continue
+		}
+		assignAt, err := time.Parse(time.RFC3339, p.AssignAt)
+		if err != nil {
+			continue
+		}
+		remaining := time.Until(assignAt)
+		if remaining <= 0 {
+			go fireJoinRoles(s, guildID, p.UserID)
+		} else {
+			scheduleJoinRoles(s, guildID, p.UserID, remaining)
+		}
 	}
 }
 
@@ -220,11 +620,33 @@ func handleRoleMenuCommand(s *discordgo.Session, i *discordgo.InteractionCreate)
 	}
 	sub := i.ApplicationCommandData().Options[0]
 
+	switch sub.Name {
+	case "add", "remove", "edit", "move", "post":
+		menuID := subOptMap(sub.Options)["menu_id"].StringValue()
+		gs := storage.GetGuild(i.GuildID)
+		gs.Lock()
+		idx := roleMenuIndex(gs, menuID)
+		isReaction := idx >= 0 && gs.RoleMenus[idx].Reactions
+		gs.Unlock()
+		if isReaction {
+			respond(s, i, lang.T("rolemenu_is_reaction_menu", "id", menuID), true)
+			return
+		}
+	}
+
 	switch sub.Name {
 	case "create":
 		handleRoleMenuCreate(s, i, sub.Options)
 	case "add":
 		handleRoleMenuAdd(s, i, sub.Options)
+	case "rules":
+		handleRoleMenuRules(s, i, sub.Options)
+	case "remove":
+		handleRoleMenuRemove(s, i, sub.Options)
+	case "edit":
+		handleRoleMenuEdit(s, i, sub.Options)
+	case "move":
+		handleRoleMenuMove(s, i, sub.Options)
 	case "post":
 		handleRoleMenuPost(s, i, sub.Options)
 	case "list":
@@ -248,7 +670,7 @@ func handleRoleMenuCreate(s *discordgo.Session, i *discordgo.InteractionCreate,
 
 	gs := storage.GetGuild(i.GuildID)
 	gs.Lock()
-	menuID := fmt.Sprintf("menu%d", len(gs.RoleMenus)+1)
+	menuID := nextRoleMenuID(gs)
 	menu := config.RoleMenu{
 		ID:           menuID,
 		Title:        title,
@@ -277,6 +699,24 @@ func handleRoleMenuAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opt
 	role := om["role"].RoleValue(s, i.GuildID)
 	label := om["label"].StringValue()
 
+	entry := config.RoleMenuEntry{
+		RoleID: role.ID,
+		Label:  label,
+	}
+	if r, ok := om["requires"]; ok {
+		entry.RequiredRoleID = r.RoleValue(s, i.GuildID).ID
+	}
+	if g, ok := om["group"]; ok {
+		entry.ExclusionGroup = strings.ToLower(strings.TrimSpace(g.StringValue()))
+	}
+	if d, ok := om["duration"]; ok {
+		if dur, err := parseDuration(d.StringValue()); err != nil || dur <= 0 {
+			respond(s, i, lang.T("temprole_invalid_duration"), true)
+			return
+		}
+		entry.Duration = d.StringValue()
+	}
+
 	gs := storage.GetGuild(i.GuildID)
 	gs.Lock()
 	found := false
@@ -287,10 +727,7 @@ func handleRoleMenuAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opt
 				respond(s, i, lang.T("rolemenu_max_roles"), true)
 				return
 			}
-			gs.RoleMenus[idx].Roles = append(gs.RoleMenus[idx].Roles, config.RoleMenuEntry{
-				RoleID: role.ID,
-				Label:  label,
-			})
+			gs.RoleMenus[idx].Roles = append(gs.RoleMenus[idx].Roles, entry)
 			found = true
 			break
 		}
@@ -302,7 +739,174 @@ func handleRoleMenuAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opt
 		return
 	}
 	_ = gs.Save()
-	respond(s, i, lang.T("rolemenu_role_added", "label", label, "role_id", role.ID, "id", menuID), true)
+	respondRoleMenuChange(s, i, menuID, lang.T("rolemenu_role_added", "label", label, "role_id", role.ID, "id", menuID))
+}
+
+func handleRoleMenuRules(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	menuID := om["menu_id"].StringValue()
+	role := om["role"].RoleValue(s, i.GuildID)
+
+	if d, ok := om["duration"]; ok {
+		if dur, err := parseDuration(d.StringValue()); err != nil || dur <= 0 {
+			respond(s, i, lang.T("temprole_invalid_duration"), true)
+			return
+		}
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_not_found", "id", menuID), true)
+		return
+	}
+	pos := roleMenuEntryIndex(gs.RoleMenus[idx].Roles, role.ID)
+	if pos < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_role_not_in_menu", "role_id", role.ID, "id", menuID), true)
+		return
+	}
+	entry := &gs.RoleMenus[idx].Roles[pos]
+	if c, ok := om["clear"]; ok && c.BoolValue() {
+		entry.RequiredRoleID = ""
+		entry.ExclusionGroup = ""
+		entry.Duration = ""
+	}
+	if r, ok := om["requires"]; ok {
+		entry.RequiredRoleID = r.RoleValue(s, i.GuildID).ID
+	}
+	if g, ok := om["group"]; ok {
+		entry.ExclusionGroup = strings.ToLower(strings.TrimSpace(g.StringValue()))
+	}
+	if d, ok := om["duration"]; ok {
+		entry.Duration = d.StringValue()
+	}
+	requires := "—"
+	if entry.RequiredRoleID != "" {
+		requires = fmt.Sprintf("<@&%s>", entry.RequiredRoleID)
+	}
+	group := "—"
+	if entry.ExclusionGroup != "" {
+		group = "`" + entry.ExclusionGroup + "`"
+	}
+	duration := "—"
+	if entry.Duration != "" {
+		duration = "`" + entry.Duration + "`"
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	respondRoleMenuChange(s, i, menuID, lang.T("rolemenu_rules_updated",
+		"role_id", role.ID,
+		"requires", requires,
+		"group", group,
+		"duration", duration,
+	))
+}
+
+func handleRoleMenuRemove(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	menuID := om["menu_id"].StringValue()
+	role := om["role"].RoleValue(s, i.GuildID)
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_not_found", "id", menuID), true)
+		return
+	}
+	entries := gs.RoleMenus[idx].Roles
+	pos := roleMenuEntryIndex(entries, role.ID)
+	if pos < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_role_not_in_menu", "role_id", role.ID, "id", menuID), true)
+		return
+	}
+	gs.RoleMenus[idx].Roles = append(entries[:pos:pos], entries[pos+1:]...)
+	gs.Unlock()
+	_ = gs.Save()
+
+	respondRoleMenuChange(s, i, menuID, lang.T("rolemenu_role_removed_entry", "role_id", role.ID, "id", menuID))
+}
+
+func handleRoleMenuEdit(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	menuID := om["menu_id"].StringValue()
+
+	_, hasTitle := om["title"]
+	_, hasDesc := om["description"]
+	_, hasSingle := om["single"]
+	if !hasTitle && !hasDesc && !hasSingle {
+		respond(s, i, lang.T("rolemenu_edit_nothing"), true)
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_not_found", "id", menuID), true)
+		return
+	}
+	if hasTitle {
+		gs.RoleMenus[idx].Title = om["title"].StringValue()
+	}
+	if hasDesc {
+		gs.RoleMenus[idx].Description = om["description"].StringValue()
+	}
+	if hasSingle {
+		gs.RoleMenus[idx].SingleSelect = om["single"].BoolValue()
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	respondRoleMenuChange(s, i, menuID, lang.T("rolemenu_edited", "id", menuID))
+}
+
+func handleRoleMenuMove(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	menuID := om["menu_id"].StringValue()
+	role := om["role"].RoleValue(s, i.GuildID)
+	target := int(om["position"].IntValue()) - 1
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_not_found", "id", menuID), true)
+		return
+	}
+	entries := gs.RoleMenus[idx].Roles
+	pos := roleMenuEntryIndex(entries, role.ID)
+	if pos < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_role_not_in_menu", "role_id", role.ID, "id", menuID), true)
+		return
+	}
+	if target < 0 {
+		target = 0
+	}
+	if target > len(entries)-1 {
+		target = len(entries) - 1
+	}
+	entry := entries[pos]
+	entries = append(entries[:pos:pos], entries[pos+1:]...)
+	entries = append(entries[:target], append([]config.RoleMenuEntry{entry}, entries[target:]...)...)
+	gs.RoleMenus[idx].Roles = entries
+	gs.Unlock()
+	_ = gs.Save()
+
+	respondRoleMenuChange(s, i, menuID, lang.T("rolemenu_moved",
+		"role_id", role.ID,
+		"position", strconv.Itoa(target+1),
+		"id", menuID,
+	))
 }
 
 func handleRoleMenuPost(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
@@ -333,30 +937,162 @@ func handleRoleMenuPost(s *discordgo.Session, i *discordgo.InteractionCreate, op
 	}
 	gs.Unlock()
 
-	embed := buildRoleMenuEmbed(menuCopy)
-	components := buildRoleMenuComponents(menuCopy)
+	if err := sendRoleMenu(s, i.GuildID, menuCopy, ch.ID); err != nil {
+		respond(s, i, lang.T("rolemenu_post_failed", "error", err.Error()), true)
+		return
+	}
+
+	respond(s, i, lang.T("rolemenu_posted", "title", menuCopy.Title, "channel_id", ch.ID), true)
+}
 
-	msg, err := s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
-		Embeds:     []*discordgo.MessageEmbed{embed},
-		Components: components,
+// sendRoleMenu posts a fresh copy of the menu and records where it lives.
+func sendRoleMenu(s *discordgo.Session, guildID string, menu *config.RoleMenu, channelID string) error {
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{buildRoleMenuEmbed(menu)},
+		Components: buildRoleMenuComponents(menu),
 	})
 	if err != nil {
-		respond(s, i, lang.T("rolemenu_post_failed", "error", err.Error()), true)
-		return
+		return err
 	}
 
+	gs := storage.GetGuild(guildID)
 	gs.Lock()
-	for idx := range gs.RoleMenus {
-		if gs.RoleMenus[idx].ID == menuID {
-			gs.RoleMenus[idx].ChannelID = ch.ID
-			gs.RoleMenus[idx].MessageID = msg.ID
-			break
-		}
+	if idx := roleMenuIndex(gs, menu.ID); idx >= 0 {
+		gs.RoleMenus[idx].ChannelID = channelID
+		gs.RoleMenus[idx].MessageID = msg.ID
 	}
 	gs.Unlock()
 	_ = gs.Save()
+	return nil
+}
 
-	respond(s, i, lang.T("rolemenu_posted", "title", menuCopy.Title, "channel_id", ch.ID), true)
+// errRoleMenuMessageGone is returned by refreshRoleMenuMessage when the posted
+// message (or its channel) was deleted behind the bot's back.
+var errRoleMenuMessageGone = errors.New("role menu message no longer exists")
+
+// refreshRoleMenuMessage re-renders an already-posted menu in place.
+// It returns posted=false when the menu has never been posted.
+func refreshRoleMenuMessage(s *discordgo.Session, guildID, menuID string) (posted bool, err error) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 || gs.RoleMenus[idx].MessageID == "" || gs.RoleMenus[idx].Reactions {
+		gs.Unlock()
+		return false, nil
+	}
+	menu := gs.RoleMenus[idx]
+	menu.Roles = append([]config.RoleMenuEntry(nil), menu.Roles...)
+	gs.Unlock()
+
+	components := buildRoleMenuComponents(&menu)
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    menu.ChannelID,
+		ID:         menu.MessageID,
+		Embeds:     &[]*discordgo.MessageEmbed{buildRoleMenuEmbed(&menu)},
+		Components: &components,
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil &&
+		(restErr.Message.Code == discordgo.ErrCodeUnknownMessage || restErr.Message.Code == discordgo.ErrCodeUnknownChannel) {
+		gs.Lock()
+		if idx := roleMenuIndex(gs, menuID); idx >= 0 {
+			gs.RoleMenus[idx].MessageID = ""
+		}
+		gs.Unlock()
+		_ = gs.Save()
+		return true, errRoleMenuMessageGone
+	}
+	return true, err
+}
+
+// respondRoleMenuChange confirms an edit to the admin and pushes it to the posted
+// message. If that message was deleted, a button is offered to post it again.
+func respondRoleMenuChange(s *discordgo.Session, i *discordgo.InteractionCreate, menuID, content string) {
+	posted, err := refreshRoleMenuMessage(s, i.GuildID, menuID)
+	if !posted {
+		respond(s, i, content, true)
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	channelID := ""
+	if idx := roleMenuIndex(gs, menuID); idx >= 0 {
+		channelID = gs.RoleMenus[idx].ChannelID
+	}
+	gs.Unlock()
+
+	switch {
+	case err == nil:
+		respond(s, i, content+"\n"+lang.T("rolemenu_message_updated", "channel_id", channelID), true)
+	case errors.Is(err, errRoleMenuMessageGone):
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content + "\n" + lang.T("rolemenu_message_gone", "channel_id", channelID),
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{
+						Components: []discordgo.MessageComponent{
+							discordgo.Button{
+								Label:    lang.T("rolemenu_repost_btn"),
+								Style:    discordgo.PrimaryButton,
+								CustomID: "rolemenu_repost:" + menuID,
+							},
+						},
+					},
+				},
+			},
+		})
+	default:
+		respond(s, i, content+"\n"+lang.T("rolemenu_message_update_failed", "error", err.Error()), true)
+	}
+}
+
+// HandleRoleMenuRepost posts a menu again in its last channel after the
+// original message was found to be deleted.
+func HandleRoleMenuRepost(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	menuID := strings.TrimPrefix(i.MessageComponentData().CustomID, "rolemenu_repost:")
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := roleMenuIndex(gs, menuID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("rolemenu_gone"), true)
+		return
+	}
+	menu := gs.RoleMenus[idx]
+	menu.Roles = append([]config.RoleMenuEntry(nil), menu.Roles...)
+	gs.Unlock()
+
+	if len(menu.Roles) == 0 {
+		respond(s, i, lang.T("rolemenu_no_roles"), true)
+		return
+	}
+	if err := sendRoleMenu(s, i.GuildID, &menu, menu.ChannelID); err != nil {
+		respond(s, i, lang.T("rolemenu_post_failed", "error", err.Error()), true)
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    lang.T("rolemenu_reposted", "channel_id", menu.ChannelID),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
 }
 
 func handleRoleMenuList(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -382,6 +1118,9 @@ func handleRoleMenuList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if m.SingleSelect {
 			mode = "single-select"
 		}
+		if m.Reactions {
+			mode = "reactions"
+		}
 		sb.WriteString(fmt.Sprintf("`%s` — **%s** | %d role(s) | %s | %s\n", m.ID, m.Title, len(m.Roles), mode, posted))
 	}
 	respond(s, i, sb.String(), true)
@@ -438,8 +1177,28 @@ func HandleRoleMenuButton(s *discordgo.Session, i *discordgo.InteractionCreate)
 	}
 	isSingle := menu.SingleSelect
 	menuRoleIDs := make([]string, len(menu.Roles))
+	var entry config.RoleMenuEntry
 	for idx, r := range menu.Roles {
 		menuRoleIDs[idx] = r.RoleID
+		if r.RoleID == roleID {
+			entry = r
+		}
+	}
+
+	// Collect every other role sharing this entry's exclusion group, across all menus.
+	// Roles in the same single-select menu are swapped below, so they don't conflict.
+	groupRoles := make(map[string]bool)
+	if entry.ExclusionGroup != "" {
+		for _, m := range gs.RoleMenus {
+			if m.ID == menuID && isSingle {
+				continue
+			}
+			for _, r := range m.Roles {
+				if r.ExclusionGroup == entry.ExclusionGroup && r.RoleID != roleID {
+					groupRoles[r.RoleID] = true
+				}
+			}
+		}
 	}
 	gs.Unlock()
 
@@ -459,20 +1218,49 @@ func HandleRoleMenuButton(s *discordgo.Session, i *discordgo.InteractionCreate)
 
 	if hasRole {
 		_ = s.GuildMemberRoleRemove(i.GuildID, userID, roleID)
+		clearMenuRoleExpiry(i.GuildID, userID, roleID)
 		respond(s, i, lang.T("rolemenu_role_removed", "role_id", roleID), true)
 	} else {
+		if reason := roleMenuRefusal(member, entry, groupRoles); reason != "" {
+			respond(s, i, reason, true)
+			return
+		}
 		if isSingle {
 			for _, rid := range menuRoleIDs {
 				if rid != roleID {
 					_ = s.GuildMemberRoleRemove(i.GuildID, userID, rid)
+					clearMenuRoleExpiry(i.GuildID, userID, rid)
 				}
 			}
 		}
 		_ = s.GuildMemberRoleAdd(i.GuildID, userID, roleID)
+		if expiresAt, ok := startMenuRoleExpiry(s, i.GuildID, i.Member.User, entry, menuID); ok {
+			respond(s, i, lang.T("rolemenu_role_given_temp", "role_id", roleID, "timestamp", fmt.Sprintf("%d", expiresAt.Unix())), true)
+			return
+		}
 		respond(s, i, lang.T("rolemenu_role_given", "role_id", roleID), true)
 	}
 }
 
+// roleMenuRefusal explains why a member may not pick an entry, or returns "".
+func roleMenuRefusal(member *discordgo.Member, entry config.RoleMenuEntry, groupRoles map[string]bool) string {
+	if entry.RequiredRoleID != "" {
+		if !memberHasRole(member, entry.RequiredRoleID) {
+			return lang.T("rolemenu_requires_role", "role_id", entry.RoleID, "required_id", entry.RequiredRoleID)
+		}
+	}
+	for _, rid := range member.Roles {
+		if groupRoles[rid] {
+			return lang.T("rolemenu_group_conflict",
+				"role_id", entry.RoleID,
+				"conflict_id", rid,
+				"group", entry.ExclusionGroup,
+			)
+		}
+	}
+	return ""
+}
+
 func buildRoleMenuEmbed(menu *config.RoleMenu) *discordgo.MessageEmbed {
 	desc := menu.Description
 	if desc == "" {
@@ -483,6 +1271,14 @@ func buildRoleMenuEmbed(menu *config.RoleMenu) *discordgo.MessageEmbed {
 	} else {
 		desc += "\n\n> ℹ️ **Multi-select:** You can pick multiple roles. Click a role again to remove it."
 	}
+	for _, r := range menu.Roles {
+		if r.RequiredRoleID != "" {
+			desc += fmt.Sprintf("\n🔒 **%s** requires <@&%s>", r.Label, r.RequiredRoleID)
+		}
+		if r.Duration != "" {
+			desc += fmt.Sprintf("\n⏳ **%s** expires after `%s`", r.Label, r.Duration)
+		}
+	}
 
 	return &discordgo.MessageEmbed{
 		Title:       "🎭 " + menu.Title,
@@ -511,3 +1307,37 @@ func buildRoleMenuComponents(menu *config.RoleMenu) []discordgo.MessageComponent
 
 	return rows
 }
+
+// roleMenuIndex returns the index of the menu in gs.RoleMenus, or -1.
+// The caller must hold the guild lock.
+func roleMenuIndex(gs *config.GuildState, menuID string) int {
+	for idx := range gs.RoleMenus {
+		if gs.RoleMenus[idx].ID == menuID {
+			return idx
+		}
+	}
+	return -1
+}
+
+func roleMenuEntryIndex(entries []config.RoleMenuEntry, roleID string) int {
+	for idx, e := range entries {
+		if e.RoleID == roleID {
+			return idx
+		}
+	}
+	return -1
+}
+
+// nextRoleMenuID returns a menu ID that has never been used in the guild. The
+// counter starts above any menu created before it existed. The caller must
+// hold the guild lock.
+func nextRoleMenuID(gs *config.GuildState) string {
+	for _, m := range gs.RoleMenus {
+		var n int
+		if _, err := fmt.Sscanf(m.ID, "menu%d", &n); err == nil && n > gs.RoleMenuCounter {
+			gs.RoleMenuCounter = n
+		}
+	}
+	gs.RoleMenuCounter++
+	return fmt.Sprintf("menu%d", gs.RoleMenuCounter)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"discord-bot/config"
+	"discord-bot/storage"
 
 	"github.com/bwmarrin/discordgo"
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -244,11 +245,12 @@ func (b *ChatBridge) handleMCMessage(raw string) {
 	}
 
 	// Look up if this Minecraft player has a linked Discord account.
-	discordMention := ""
+	discordID, discordMention := "", ""
 	if MCStore != nil {
 		if links, err := MCStore.ListLinks(); err == nil {
 			for _, l := range links {
 				if l.Username == playerName {
+					discordID = l.DiscordID
 					discordMention = fmt.Sprintf(" (<@%s>)", l.DiscordID)
 					break
 				}
@@ -256,6 +258,15 @@ func (b *ChatBridge) handleMCMessage(raw string) {
 		}
 	}
 
+	// Run the message through the guild's content filter before relaying it.
+	guildID := storage.Cfg.Discord.GuildID
+	if ch, err := b.session.State.Channel(b.cfg.ChannelID); err == nil {
+		guildID = ch.GuildID
+	}
+	if guildID != "" && filterBridgeMessage(b.session, guildID, b.cfg.ChannelID, discordID, playerName, message) {
+		return
+	}
+
 	text := fmt.Sprintf("**%s**%s: %s", playerName, discordMention, message)
 	if _, err := b.session.ChannelMessageSend(b.cfg.ChannelID, text); err != nil {
 		log.Printf("[ChatBridge] Discord send error: %v", err)
@@ -14,9 +14,25 @@ import (
 func Commands(cfg *config.Config) []*discordgo.ApplicationCommand {
 	cmds := make([]*discordgo.ApplicationCommand, 0)
 	cmds = append(cmds, moderationCommands()...)
+	cmds = append(cmds, massBanCommands()...)
 	cmds = append(cmds, ticketCommands()...)
 	cmds = append(cmds, utilityCommands()...)
 	cmds = append(cmds, autoroleCommands()...)
+	cmds = append(cmds, reactionRoleCommands()...)
+	cmds = append(cmds, tempRoleCommands()...)
+	cmds = append(cmds, stickyRoleCommands()...)
+	cmds = append(cmds, verificationCommands()...)
+	cmds = append(cmds, raidCommands()...)
+	cmds = append(cmds, lockdownCommands()...)
+	cmds = append(cmds, filterCommands()...)
+	cmds = append(cmds, linkFilterCommands()...)
+	cmds = append(cmds, appealCommands()...)
+	cmds = append(cmds, noteCommands()...)
+	cmds = append(cmds, modStatsCommands()...)
+	cmds = append(cmds, reportCommands()...)
+	cmds = append(cmds, contextMenuCommands()...)
+	cmds = append(cmds, nicknameCommands()...)
+	cmds = append(cmds, auditCommands()...)
 	cmds = append(cmds, giveawayCommands()...)
 	if cfg.Minecraft.Enabled {
 		cmds = append(cmds, minecraftCommands()...)
@@ -53,14 +69,24 @@ var customCommandMap map[string]config.CustomCommandConfig
 func Register(s *discordgo.Session) {
 	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if i.GuildID == "" {
+			handleDMInteraction(s, i)
 			return
 		}
 
 		switch i.Type {
 		case discordgo.InteractionApplicationCommand:
-			handleSlashCommand(s, i)
+			switch i.ApplicationCommandData().CommandType {
+			case discordgo.UserApplicationCommand:
+				handleUserCommand(s, i)
+			case discordgo.MessageApplicationCommand:
+				handleMessageCommand(s, i)
+			default:
+				handleSlashCommand(s, i)
+			}
 		case discordgo.InteractionMessageComponent:
 			handleComponent(s, i)
+		case discordgo.InteractionModalSubmit:
+			handleModalSubmit(s, i)
 		}
 	})
 }
@@ -83,6 +109,10 @@ func handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch name {
 	case "ban":
 		handleBan(s, i)
+	case "softban":
+		handleSoftban(s, i)
+	case "massban":
+		handleMassban(s, i)
 	case "unban":
 		handleUnban(s, i)
 	case "kick":
@@ -134,6 +164,34 @@ func handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		handleJoinRoleCommand(s, i)
 	case "rolemenu":
 		handleRoleMenuCommand(s, i)
+	case "reactionrole":
+		handleReactionRoleCommand(s, i)
+	case "temprole":
+		handleTempRoleCommand(s, i)
+	case "stickyroles":
+		handleStickyRolesCommand(s, i)
+	case "verification":
+		handleVerificationCommand(s, i)
+	case "raid":
+		handleRaidCommand(s, i)
+	case "lockdown":
+		handleLockdownCommand(s, i)
+	case "filter":
+		handleFilterCommand(s, i)
+	case "linkfilter":
+		handleLinkFilterCommand(s, i)
+	case "appeals":
+		handleAppealsCommand(s, i)
+	case "note":
+		handleNoteCommand(s, i)
+	case "modstats":
+		handleModStatsCommand(s, i)
+	case "reports":
+		handleReportsCommand(s, i)
+	case "dehoist":
+		handleDehoistCommand(s, i)
+	case "audit":
+		handleAuditCommand(s, i)
 	case "giveaway":
 		handleGiveawayCommand(s, i)
 
@@ -147,6 +205,36 @@ func handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 }
 
+// handleUserCommand routes user context-menu commands (right-click a member).
+func handleUserCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := i.ApplicationCommandData().Name
+
+	switch name {
+	case ctxWarn:
+		handleContextWarn(s, i)
+	case ctxTimeout:
+		handleContextTimeout(s, i)
+	case ctxViewCases:
+		handleContextCases(s, i)
+	case ctxUserInfo:
+		handleUserinfo(s, i)
+	default:
+		log.Printf("Unknown user command: %s", name)
+	}
+}
+
+// handleMessageCommand routes message context-menu commands.
+func handleMessageCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := i.ApplicationCommandData().Name
+
+	switch name {
+	case "Report message":
+		handleReportMessage(s, i)
+	default:
+		log.Printf("Unknown message command: %s", name)
+	}
+}
+
 func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	customID := i.MessageComponentData().CustomID
 
@@ -154,6 +242,10 @@ func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		HandleRoleMenuButton(s, i)
 		return
 	}
+	if strings.HasPrefix(customID, "rolemenu_repost:") {
+		HandleRoleMenuRepost(s, i)
+		return
+	}
 	if strings.HasPrefix(customID, "giveaway_enter:") {
 		HandleGiveawayEnter(s, i)
 		return
@@ -161,6 +253,14 @@ func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if strings.HasPrefix(customID, "giveaway_ended_") {
 		return
 	}
+	if strings.HasPrefix(customID, "appeal_accept:") || strings.HasPrefix(customID, "appeal_deny:") {
+		HandleAppealDecision(s, i)
+		return
+	}
+	if strings.HasPrefix(customID, "report:") {
+		HandleReportAction(s, i)
+		return
+	}
 
 	switch customID {
 	case "ticket_category_select":
@@ -173,11 +273,33 @@ func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		handleCloseConfirm(s, i)
 	case "ticket_close_cancel":
 		handleCloseCancel(s, i)
+	case "verify_start":
+		HandleVerifyStart(s, i)
 	default:
 		log.Printf("Unknown component: %s", customID)
 	}
 }
 
+func handleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.ModalSubmitData().CustomID
+
+	if strings.HasPrefix(customID, "report_modal:") {
+		HandleReportModal(s, i)
+		return
+	}
+	if strings.HasPrefix(customID, "ctx_warn:") || strings.HasPrefix(customID, "ctx_timeout:") {
+		HandleContextModal(s, i)
+		return
+	}
+
+	switch customID {
+	case "verify_modal":
+		HandleVerifyModal(s, i)
+	default:
+		log.Printf("Unknown modal: %s", customID)
+	}
+}
+
 func respond(s *discordgo.Session, i *discordgo.InteractionCreate, content string, ephemeral bool) {
 	flags := discordgo.MessageFlags(0)
 	if ephemeral {
@@ -209,6 +331,15 @@ func respondEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *d
 	})
 }
 
+// deferEphemeral acknowledges an interaction whose work may take longer than
+// Discord's three-second deadline; the result is then sent with followup.
+func deferEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
 func followup(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
 	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Content: content,
@@ -216,6 +347,23 @@ func followup(s *discordgo.Session, i *discordgo.InteractionCreate, content stri
 	})
 }
 
+// followupPublic swaps the ephemeral placeholder left by deferEphemeral for a
+// message the whole channel sees. A follow-up to a deferred reply would
+// otherwise inherit its ephemeral flag.
+func followupPublic(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	_ = s.InteractionResponseDelete(i.Interaction)
+	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: content})
+}
+
+// cachedMember returns a member from the state cache, fetching it over REST
+// only when the cache misses.
+func cachedMember(s *discordgo.Session, guildID, userID string) (*discordgo.Member, error) {
+	if m, err := s.State.Member(guildID, userID); err == nil {
+		return m, nil
+	}
+	return s.GuildMember(guildID, userID)
+}
+
 func optionMap(i *discordgo.InteractionCreate) map[string]*discordgo.ApplicationCommandInteractionDataOption {
 	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
 	for _, opt := range i.ApplicationCommandData().Options {
@@ -246,6 +394,13 @@ func optInt(m map[string]*discordgo.ApplicationCommandInteractionDataOption, key
 	return def
 }
 
+func optBool(m map[string]*discordgo.ApplicationCommandInteractionDataOption, key string, def bool) bool {
+	if o, ok := m[key]; ok {
+		return o.BoolValue()
+	}
+	return def
+}
+
 // resolveCustomPlaceholders replaces Discord-native placeholders in a custom command message.
 //
 //	{user}         → mentions the user who ran the command
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// User context-menu command names, as shown in Discord's Apps menu.
+const (
+	ctxWarn      = "Warn"
+	ctxTimeout   = "Timeout 10m"
+	ctxViewCases = "View cases"
+	ctxUserInfo  = "User info"
+)
+
+// contextTimeout is the length of the "Timeout 10m" action.
+const (
+	contextTimeout    = 10 * time.Minute
+	contextTimeoutStr = "10m"
+)
+
+// maxContextCases is how many recent cases "View cases" lists.
+const maxContextCases = 10
+
+func contextMenuCommands() []*discordgo.ApplicationCommand {
+	cmds := make([]*discordgo.ApplicationCommand, 0, 4)
+	for _, name := range []string{ctxWarn, ctxTimeout, ctxViewCases, ctxUserInfo} {
+		cmds = append(cmds, &discordgo.ApplicationCommand{
+			Name:                     name,
+			Type:                     discordgo.UserApplicationCommand,
+			DefaultMemberPermissions: &modPermission,
+		})
+	}
+	return cmds
+}
+
+// contextMenuUser returns the member a user command was used on, or nil for
+// other interactions.
+func contextMenuUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return nil
+	}
+	data := i.ApplicationCommandData()
+	if data.CommandType != discordgo.UserApplicationCommand || data.Resolved == nil {
+		return nil
+	}
+	return data.Resolved.Users[data.TargetID]
+}
+
+// openReasonModal asks for a reason before a context-menu action on target.
+func openReasonModal(s *discordgo.Session, i *discordgo.InteractionCreate, prefix, title string, target *discordgo.User, required bool) {
+	// Discord rejects modal titles longer than 45 characters.
+	if r := []rune(title); len(r) > 45 {
+		title = string(r[:44]) + "…"
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: prefix + ":" + target.ID,
+			Title:    title,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "reason",
+						Label:     lang.T("ctx_reason_label"),
+						Style:     discordgo.TextInputParagraph,
+						Required:  required,
+						MaxLength: 500,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[ContextMenu] Failed to open %s modal: %v", prefix, err)
+	}
+}
+
+// contextTarget resolves and vets the member a context-menu action targets.
+func contextTarget(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.User {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return nil
+	}
+	target := contextMenuUser(i)
+	if target == nil {
+		respond(s, i, lang.T("mod_fetch_member_failed", "error", "unknown user"), true)
+		return nil
+	}
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		respond(s, i, problem, true)
+		return nil
+	}
+	return target
+}
+
+func handleContextWarn(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if target := contextTarget(s, i); target != nil {
+		openReasonModal(s, i, "ctx_warn", lang.T("ctx_warn_title", "user", target.Username), target, true)
+	}
+}
+
+func handleContextTimeout(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if target := contextTarget(s, i); target != nil {
+		openReasonModal(s, i, "ctx_timeout", lang.T("ctx_timeout_title", "user", target.Username), target, false)
+	}
+}
+
+// HandleContextModal carries out a warn or timeout once its reason modal is
+// submitted, through the same path as /warn and /mute.
+func HandleContextModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	data := i.ModalSubmitData()
+	prefix, userID, ok := strings.Cut(data.CustomID, ":")
+	if !ok {
+		return
+	}
+	reason := strings.TrimSpace(modalValue(data, "reason"))
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	// The checks, DM and action easily take longer than three seconds.
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, userID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+	target, err := s.User(userID)
+	if err != nil {
+		followup(s, i, lang.T("mod_fetch_member_failed", "error", err.Error()))
+		return
+	}
+
+	switch prefix {
+	case "ctx_warn":
+		w := warnMember(s, i.GuildID, target, i.Member.User, reason, false)
+		followup(s, i, lang.T("mod_warn_success", "user", target.Username, "id", strconv.Itoa(w.ID), "reason", reason))
+	case "ctx_timeout":
+		if err := timeoutMember(s, i.GuildID, target, i.Member.User, contextTimeout, contextTimeoutStr, reason, false); err != nil {
+			followup(s, i, lang.T("mod_mute_failed", "error", err.Error()))
+			return
+		}
+		followup(s, i, lang.T("mod_mute_success", "user", target.Username, "duration", contextTimeoutStr, "reason", reason))
+	}
+}
+
+// handleContextCases shows a member's most recent mod cases.
+func handleContextCases(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	target := contextMenuUser(i)
+	if target == nil {
+		respond(s, i, lang.T("mod_fetch_member_failed", "error", "unknown user"), true)
+		return
+	}
+	if storage.DB == nil {
+		respond(s, i, lang.T("ctx_cases_no_database"), true)
+		return
+	}
+	cases, err := storage.DB.GetModCases(i.GuildID, target.ID, maxContextCases)
+	if err != nil {
+		respond(s, i, lang.T("ctx_cases_failed", "error", err.Error()), true)
+		return
+	}
+	if len(cases) == 0 {
+		respond(s, i, lang.T("ctx_cases_none", "user", target.Username), true)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: lang.T("ctx_cases_title", "user", target.Username),
+		Color: 0x5865F2,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: target.AvatarURL("128"),
+		},
+	}
+	for _, c := range cases {
+		when := c.Timestamp
+		if t, err := time.Parse(time.RFC3339, c.Timestamp); err == nil {
+			when = fmt.Sprintf("<t:%d:d>", t.Unix())
+		}
+		value := lang.T("ctx_cases_entry", "reason", c.Reason, "mod_id", c.ModID, "date", when)
+		if c.Duration != "" {
+			value += "\n" + lang.T("ctx_cases_duration", "duration", c.Duration)
+		}
+		if c.AppealStatus != "" {
+			value += "\n" + lang.T("ctx_cases_appeal", "status", c.AppealStatus)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  lang.T("ctx_cases_entry_title", "id", strconv.Itoa(c.ID), "action", c.Action),
+			Value: value,
+		})
+	}
+	respondEmbed(s, i, embed, true)
+}
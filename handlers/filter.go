@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// filterPatterns caches compiled rules, keyed by type and pattern.
+	filterPatterns   = make(map[string]*regexp.Regexp)
+	filterPatternsMu sync.Mutex
+)
+
+// leetReplacer undoes common character substitutions.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"@", "a", "$", "s", "!", "i", "|", "l", "+", "t",
+)
+
+// confusables maps lookalike letters (Cyrillic, Greek, accented Latin) to the
+// ASCII letter they imitate.
+var confusables = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j', 'ѕ': 's',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ç': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y', 'ß': 's',
+}
+
+func filterCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "filter",
+			Description:              "Manage the word and regex content filter",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Description: "Add a filter rule",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "pattern", Description: "Word, wildcard (e.g. bad*) or regular expression", Required: true},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "type",
+							Description: "How the pattern is matched (default: word)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Word", Value: "word"},
+								{Name: "Wildcard", Value: "wildcard"},
+								{Name: "Regex", Value: "regex"},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "action",
+							Description: "What to do on a match (default: delete)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Delete", Value: "delete"},
+								{Name: "Delete and warn", Value: "warn"},
+								{Name: "Delete and timeout", Value: "timeout"},
+								{Name: "Log only", Value: "log"},
+							},
+						},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Timeout length for the timeout action (e.g. 10m)"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "exempt_channels", Description: "Channels where this rule doesn't apply (mentions or IDs)"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "exempt_roles", Description: "Roles this rule doesn't apply to (mentions or IDs)"},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Remove a filter rule",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "id", Description: "Rule ID (see /filter list)", Required: true},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "List filter rules",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "test",
+					Description: "Check which rules a piece of text would trigger",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "text", Description: "Text to test", Required: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+// RegisterFilter checks new and edited messages against the guild's filter rules.
+func RegisterFilter(s *discordgo.Session) {
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		handleFilterMessage(s, m.Message)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+		handleFilterMessage(s, m.Message)
+	})
+}
+
+func handleFilterCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "add":
+		handleFilterAdd(s, i, sub.Options)
+	case "remove":
+		handleFilterRemove(s, i, sub.Options)
+	case "list":
+		handleFilterList(s, i)
+	case "test":
+		handleFilterTest(s, i, sub.Options)
+	}
+}
+
+func handleFilterAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	rule := config.FilterRule{
+		Pattern:        strings.TrimSpace(om["pattern"].StringValue()),
+		Type:           optStr(om, "type", "word"),
+		Action:         optStr(om, "action", "delete"),
+		Duration:       strings.TrimSpace(optStr(om, "duration", "")),
+		ExemptChannels: channelIDPattern.FindAllString(optStr(om, "exempt_channels", ""), -1),
+		ExemptRoles:    channelIDPattern.FindAllString(optStr(om, "exempt_roles", ""), -1),
+		CreatedBy:      i.Member.User.ID,
+	}
+	if rule.Pattern == "" {
+		respond(s, i, lang.T("filter_empty_pattern"), true)
+		return
+	}
+	if _, err := compileFilterRule(rule); err != nil {
+		respond(s, i, lang.T("filter_invalid_regex", "error", err.Error()), true)
+		return
+	}
+	if rule.Action == "timeout" {
+		if rule.Duration == "" {
+			rule.Duration = "10m"
+		}
+		if dur, err := parseDuration(rule.Duration); err != nil || dur <= 0 {
+			respond(s, i, lang.T("filter_invalid_duration"), true)
+			return
+		}
+	} else {
+		rule.Duration = ""
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	for _, r := range gs.FilterRules {
+		if r.ID >= rule.ID {
+			rule.ID = r.ID + 1
+		}
+	}
+	if rule.ID == 0 {
+		rule.ID = 1
+	}
+	gs.FilterRules = append(gs.FilterRules, rule)
+	gs.Unlock()
+	_ = gs.Save()
+
+	respond(s, i, lang.T("filter_added",
+		"id", strconv.Itoa(rule.ID),
+		"type", rule.Type,
+		"pattern", rule.Pattern,
+		"action", rule.Action,
+	), true)
+}
+
+func handleFilterRemove(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	id := int(subOptMap(opts)["id"].IntValue())
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	found := false
+	kept := make([]config.FilterRule, 0, len(gs.FilterRules))
+	for _, r := range gs.FilterRules {
+		if r.ID == id {
+			found = true
+			continue
+		}
+		kept = append(kept, r)
+	}
+	gs.FilterRules = kept
+	gs.Unlock()
+
+	if !found {
+		respond(s, i, lang.T("filter_not_found", "id", strconv.Itoa(id)), true)
+		return
+	}
+	_ = gs.Save()
+	respond(s, i, lang.T("filter_removed", "id", strconv.Itoa(id)), true)
+}
+
+func handleFilterList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	rules := append([]config.FilterRule(nil), gs.FilterRules...)
+	gs.Unlock()
+
+	if len(rules) == 0 {
+		respond(s, i, lang.T("filter_none"), true)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lang.T("filter_list_header", "count", strconv.Itoa(len(rules))))
+	for _, r := range rules {
+		action := r.Action
+		if r.Duration != "" {
+			action += " " + r.Duration
+		}
+		sb.WriteString(lang.T("filter_list_entry",
+			"id", strconv.Itoa(r.ID),
+			"type", r.Type,
+			"pattern", r.Pattern,
+			"action", action,
+		))
+		if len(r.ExemptChannels) > 0 || len(r.ExemptRoles) > 0 {
+			sb.WriteString(lang.T("filter_list_exempt",
+				"channels", strconv.Itoa(len(r.ExemptChannels)),
+				"roles", strconv.Itoa(len(r.ExemptRoles)),
+			))
+		}
+	}
+	respond(s, i, sb.String(), true)
+}
+
+func handleFilterTest(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	text := subOptMap(opts)["text"].StringValue()
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	rules := append([]config.FilterRule(nil), gs.FilterRules...)
+	gs.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString(lang.T("filter_test_normalized", "text", normalizeFilterText(text)))
+	matched := 0
+	for _, r := range rules {
+		if filterRuleMatches(r, text) {
+			matched++
+			sb.WriteString(lang.T("filter_list_entry",
+				"id", strconv.Itoa(r.ID),
+				"type", r.Type,
+				"pattern", r.Pattern,
+				"action", r.Action,
+			))
+		}
+	}
+	if matched == 0 {
+		sb.WriteString(lang.T("filter_test_clean"))
+	}
+	respond(s, i, sb.String(), true)
+}
+
+func handleFilterMessage(s *discordgo.Session, m *discordgo.Message) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || m.Content == "" {
+		return
+	}
+
+	gs := storage.GetGuild(m.GuildID)
+	gs.Lock()
+	if len(gs.FilterRules) == 0 {
+		gs.Unlock()
+		return
+	}
+	rules := append([]config.FilterRule(nil), gs.FilterRules...)
+	gs.Unlock()
+
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	rule, ok := matchFilterRules(rules, m.Content, m.ChannelID, roles)
+	if !ok {
+		return
+	}
+	applyAutoModAction(s, m.GuildID, m.ChannelID, m.ID, m.Author, rule.Action, rule.Duration,
+		lang.T("filter_reason", "id", strconv.Itoa(rule.ID)))
+}
+
+// filterBridgeMessage runs a Minecraft chat message through the filter. It
+// returns true when the message must not be relayed to Discord. Warnings and
+// timeouts are applied to the player's linked Discord account, if any.
+func filterBridgeMessage(s *discordgo.Session, guildID, channelID, discordID, playerName, text string) bool {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	rules := append([]config.FilterRule(nil), gs.FilterRules...)
+	gs.Unlock()
+
+	rule, ok := matchFilterRules(rules, text, channelID, nil)
+	if !ok {
+		return false
+	}
+	reason := lang.T("filter_reason_bridge", "id", strconv.Itoa(rule.ID), "player", playerName)
+
+	if discordID != "" {
+		user, err := s.User(discordID)
+		if err == nil {
+			applyAutoModAction(s, guildID, channelID, "", user, rule.Action, rule.Duration, reason)
+			return rule.Action != "log"
+		}
+	}
+	logModEvent(s, guildID, lang.T("automod_log_title"),
+		lang.T("filter_log_bridge", "player", playerName, "id", strconv.Itoa(rule.ID), "action", rule.Action), 0xFEE75C)
+	return rule.Action != "log"
+}
+
+// matchFilterRules returns the first rule that applies in this channel to a
+// member with these roles and matches text.
+func matchFilterRules(rules []config.FilterRule, text, channelID string, roles []string) (config.FilterRule, bool) {
+	for _, r := range rules {
+		if filterRuleExempt(r, channelID, roles) {
+			continue
+		}
+		if filterRuleMatches(r, text) {
+			return r, true
+		}
+	}
+	return config.FilterRule{}, false
+}
+
+func filterRuleExempt(r config.FilterRule, channelID string, roles []string) bool {
+	for _, id := range r.ExemptChannels {
+		if id == channelID {
+			return true
+		}
+	}
+	for _, id := range r.ExemptRoles {
+		for _, rid := range roles {
+			if id == rid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterRuleMatches tests the rule against both the raw text and its
+// normalised form, so regexes written for either still work.
+func filterRuleMatches(r config.FilterRule, text string) bool {
+	re, err := compileFilterRule(r)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(text) || re.MatchString(normalizeFilterText(text))
+}
+
+func compileFilterRule(r config.FilterRule) (*regexp.Regexp, error) {
+	key := r.Type + ":" + r.Pattern
+	filterPatternsMu.Lock()
+	defer filterPatternsMu.Unlock()
+	if re, ok := filterPatterns[key]; ok {
+		return re, nil
+	}
+
+	var expr string
+	switch r.Type {
+	case "regex":
+		expr = "(?i)" + r.Pattern
+	case "wildcard":
+		quoted := regexp.QuoteMeta(normalizeFilterText(r.Pattern))
+		quoted = strings.ReplaceAll(quoted, `\*`, `\S*`)
+		quoted = strings.ReplaceAll(quoted, `\?`, `\S`)
+		expr = `(?i)\b` + quoted + `\b`
+	default:
+		expr = `(?i)\b` + regexp.QuoteMeta(normalizeFilterText(r.Pattern)) + `\b`
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("rule %d: %w", r.ID, err)
+	}
+	filterPatterns[key] = re
+	return re, nil
+}
+
+// normalizeFilterText lowercases text, folds confusable and fullwidth letters
+// to ASCII, drops zero-width characters and undoes leetspeak.
+func normalizeFilterText(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == '\u200b' || r == '\u200c' || r == '\u200d' || r == '\u2060' || r == '\ufeff':
+			continue
+		case r >= '\uff01' && r <= '\uff5e':
+			r -= 0xfee0
+			if r >= 'A' && r <= 'Z' {
+				r += 'a' - 'A'
+			}
+		}
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		sb.WriteRune(r)
+	}
+	return leetReplacer.Replace(sb.String())
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxRecentBans caps how many bans per guild are kept for alt detection.
+const maxRecentBans = 500
+
+// RegisterJoinGate starts remembering bans for alt detection. The join check
+// itself runs from the member-add handler in welcome.go so it happens before
+// verification and join roles.
+func RegisterJoinGate(s *discordgo.Session, cfg *config.Config) {
+	jg := &cfg.Moderation.JoinGate
+	if !jg.Enabled {
+		return
+	}
+	if jg.AltDetection {
+		s.AddHandler(func(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+			recordBan(e.GuildID, e.User, jg.AltWindowDays)
+		})
+	}
+	log.Printf("[JoinGate] Active — accounts younger than %d day(s) are handled with %q", jg.MinAccountAgeDays, jg.Action)
+}
+
+// gateJoin checks a new member's account age and likeness to recently banned
+// users and logs the join. It returns true when the member was quarantined or
+// kicked, so the caller should skip verification and join roles.
+func gateJoin(s *discordgo.Session, guildID string, member *discordgo.Member) bool {
+	jg := &storage.Cfg.Moderation.JoinGate
+	if !jg.Enabled || member == nil || member.User == nil || member.User.Bot {
+		return false
+	}
+	user := member.User
+
+	created := snowflakeTime(user.ID)
+	age := time.Since(created)
+	young := age < time.Duration(jg.MinAccountAgeDays)*24*time.Hour
+
+	var alts []string
+	if jg.AltDetection {
+		alts = findAltMatches(guildID, user, jg.AltWindowDays)
+	}
+
+	held := false
+	outcome := ""
+	if young {
+		reason := lang.T("joingate_reason_young", "days", fmt.Sprint(jg.MinAccountAgeDays))
+		switch jg.Action {
+		case "quarantine":
+			if jg.QuarantineRole == "" {
+				break
+			}
+			if err := s.GuildMemberRoleAdd(guildID, user.ID, jg.QuarantineRole); err != nil {
+				log.Printf("[JoinGate] Failed to quarantine %s: %v", user.ID, err)
+				break
+			}
+			held = true
+			outcome = lang.T("joingate_outcome_quarantined", "role_id", jg.QuarantineRole)
+		case "kick":
+			bot := s.State.User
+			notice := notifyTarget(s, guildID, user, "kick", reason, "", false)
+			if err := s.GuildMemberDeleteWithReason(guildID, user.ID, reason); err != nil {
+				notice.retract(s)
+				log.Printf("[JoinGate] Failed to kick %s: %v", user.ID, err)
+				break
+			}
+			caseID := logModActionExtra(s, guildID, "Kick", user, bot, reason, "", notice.logField())
+			notice.attachCase(s, guildID, caseID)
+			held = true
+			outcome = lang.T("joingate_outcome_kicked")
+		}
+		if outcome == "" {
+			outcome = lang.T("joingate_outcome_logged")
+		}
+	}
+
+	postJoinLog(s, guildID, jg, user, created, young, outcome, alts)
+	return held
+}
+
+// postJoinLog writes a join entry. Without a join log channel only flagged
+// joins are reported, to the mod log.
+func postJoinLog(s *discordgo.Session, guildID string, jg *config.JoinGateConfig, user *discordgo.User, created time.Time, young bool, outcome string, alts []string) {
+	flagged := young || len(alts) > 0
+	channelID := jg.JoinLogChannel
+	if channelID == "" {
+		if !flagged {
+			return
+		}
+		channelID = config.EffectiveModLogChannel(storage.Cfg, storage.GetGuild(guildID))
+		if channelID == "" {
+			return
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       lang.T("joingate_log_title"),
+		Description: lang.T("joingate_log_desc", "user_id", user.ID, "username", user.Username, "created", fmt.Sprintf("<t:%d:R>", created.Unix())),
+		Color:       0x57F287,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if young {
+		embed.Color = 0xFEE75C
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  lang.T("joingate_young_field"),
+			Value: lang.T("joingate_young_value", "days", fmt.Sprint(jg.MinAccountAgeDays), "outcome", outcome),
+		})
+	}
+	if len(alts) > 0 {
+		embed.Color = 0xED4245
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  lang.T("joingate_alt_field"),
+			Value: strings.Join(alts, "\n"),
+		})
+	}
+	if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		log.Printf("[JoinGate] Failed to post join log: %v", err)
+	}
+}
+
+// recordBan remembers a banned user's name and avatar and drops entries older
+// than the alt window.
+func recordBan(guildID string, user *discordgo.User, windowDays int) {
+	if user == nil {
+		return
+	}
+	cutoff := time.Now().Add(-time.Duration(windowDays) * 24 * time.Hour)
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	kept := make([]config.BannedUser, 0, len(gs.RecentBans)+1)
+	for _, b := range gs.RecentBans {
+		if t, err := time.Parse(time.RFC3339, b.BannedAt); err == nil && t.After(cutoff) && b.UserID != user.ID {
+			kept = append(kept, b)
+		}
+	}
+	kept = append(kept, config.BannedUser{
+		UserID:   user.ID,
+		Username: user.Username,
+		Avatar:   user.Avatar,
+		BannedAt: time.Now().Format(time.RFC3339),
+	})
+	if len(kept) > maxRecentBans {
+		kept = kept[len(kept)-maxRecentBans:]
+	}
+	gs.RecentBans = kept
+	gs.Unlock()
+	_ = gs.Save()
+}
+
+// findAltMatches describes every recent ban that shares user's avatar or has
+// a near-identical username.
+func findAltMatches(guildID string, user *discordgo.User, windowDays int) []string {
+	cutoff := time.Now().Add(-time.Duration(windowDays) * 24 * time.Hour)
+	name := altName(user.Username)
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	bans := append([]config.BannedUser(nil), gs.RecentBans...)
+	gs.Unlock()
+
+	var matches []string
+	for _, b := range bans {
+		if b.UserID == user.ID {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, b.BannedAt); err != nil || t.Before(cutoff) {
+			continue
+		}
+		switch {
+		case user.Avatar != "" && user.Avatar == b.Avatar:
+			matches = append(matches, lang.T("joingate_alt_avatar", "user_id", b.UserID, "username", b.Username))
+		case namesSimilar(name, altName(b.Username)):
+			matches = append(matches, lang.T("joingate_alt_name", "user_id", b.UserID, "username", b.Username))
+		}
+	}
+	return matches
+}
+
+// altName reduces a username to the letters and digits that matter when
+// comparing it, after folding lookalike characters.
+func altName(username string) string {
+	var sb strings.Builder
+	for _, r := range normalizeFilterText(username) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// namesSimilar treats two normalised names as the same person when they are
+// equal or within a small edit distance. Very short names are ignored because
+// they collide too easily.
+func namesSimilar(a, b string) bool {
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	maxDist := 1
+	if len(a) >= 8 {
+		maxDist = 2
+	}
+	return levenshtein(a, b) <= maxDist
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>]+`)
+	invitePattern = regexp.MustCompile(`(?i)(?:discord(?:app)?\.com/invite|discord\.gg|discord\.me|dsc\.gg)/([a-z0-9-]+)`)
+)
+
+// phishingCheckEvery is how often message handling looks for a changed
+// phishing domain file.
+const phishingCheckEvery = 30 * time.Second
+
+// inviteCacheTTL is how long an invite lookup is trusted, and maxInviteCache
+// how many lookups are kept before expired ones are dropped.
+const (
+	inviteCacheTTL = time.Hour
+	maxInviteCache = 5000
+)
+
+var (
+	phishingDomains   = make(map[string]bool)
+	phishingModTime   time.Time
+	phishingCheckedAt time.Time
+	phishingDomainMu  sync.Mutex
+
+	// inviteGuilds caches which guild an invite code points to.
+	inviteGuilds   = make(map[string]inviteLookup)
+	inviteGuildsMu sync.Mutex
+)
+
+type inviteLookup struct {
+	guildID string
+	at      time.Time
+}
+
+func linkFilterCommands() []*discordgo.ApplicationCommand {
+	actionChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Delete", Value: "delete"},
+		{Name: "Delete and warn", Value: "warn"},
+		{Name: "Delete and timeout", Value: "timeout"},
+		{Name: "Log only", Value: "log"},
+	}
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "linkfilter",
+			Description:              "Block invites, unapproved links and phishing domains",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "settings",
+					Description: "Change link filter settings",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Turn the link filter on or off"},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "invites", Description: "Block invites to other servers"},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "links", Description: "Block links to domains that aren't allowed"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "action", Description: "What to do with offending messages", Choices: actionChoices},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Timeout length for the timeout action (e.g. 10m)"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "exempt_channels", Description: "Channels the filter ignores (mentions or IDs, replaces the list)"},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "exempt_roles", Description: "Roles the filter ignores (mentions or IDs, replaces the list)"},
+					},
+				},
+				{
+					Name:        "allow",
+					Description: "Allow links to a domain and its subdomains",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "domain", Description: "Domain (e.g. youtube.com)", Required: true},
+					},
+				},
+				{
+					Name:        "disallow",
+					Description: "Remove a domain from the allowlist",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "domain", Description: "Domain to remove", Required: true},
+					},
+				},
+				{
+					Name:        "status",
+					Description: "Show link filter settings",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "reload",
+					Description: "Reload the phishing domain list from disk",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+// RegisterLinkFilter checks new and edited messages for invites and links.
+func RegisterLinkFilter(s *discordgo.Session) {
+	if _, err := loadPhishingList(true); err != nil && !os.IsNotExist(err) {
+		log.Printf("[LinkFilter] Failed to load phishing list: %v", err)
+	}
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		handleLinkFilterMessage(s, m.Message)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+		handleLinkFilterMessage(s, m.Message)
+	})
+}
+
+func handleLinkFilterCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	gs := storage.GetGuild(i.GuildID)
+
+	switch sub.Name {
+	case "settings":
+		om := subOptMap(sub.Options)
+		if d := strings.TrimSpace(optStr(om, "duration", "")); d != "" {
+			if dur, err := parseDuration(d); err != nil || dur <= 0 {
+				respond(s, i, lang.T("filter_invalid_duration"), true)
+				return
+			}
+		}
+
+		gs.Lock()
+		lf := &gs.LinkFilter
+		if o, ok := om["enabled"]; ok {
+			lf.Enabled = o.BoolValue()
+		}
+		if o, ok := om["invites"]; ok {
+			lf.BlockInvites = o.BoolValue()
+		}
+		if o, ok := om["links"]; ok {
+			lf.BlockLinks = o.BoolValue()
+		}
+		if o, ok := om["action"]; ok {
+			lf.Action = o.StringValue()
+		}
+		if o, ok := om["duration"]; ok {
+			lf.Duration = strings.TrimSpace(o.StringValue())
+		}
+		if o, ok := om["exempt_channels"]; ok {
+			lf.ExemptChannels = channelIDPattern.FindAllString(o.StringValue(), -1)
+		}
+		if o, ok := om["exempt_roles"]; ok {
+			lf.ExemptRoles = channelIDPattern.FindAllString(o.StringValue(), -1)
+		}
+		if lf.Action == "" {
+			lf.Action = "delete"
+		}
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("linkfilter_updated"), true)
+
+	case "allow":
+		domain := normalizeDomain(subOptMap(sub.Options)["domain"].StringValue())
+		if domain == "" {
+			respond(s, i, lang.T("linkfilter_invalid_domain"), true)
+			return
+		}
+		gs.Lock()
+		exists := false
+		for _, d := range gs.LinkFilter.AllowedDomains {
+			if d == domain {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			gs.LinkFilter.AllowedDomains = append(gs.LinkFilter.AllowedDomains, domain)
+		}
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("linkfilter_allowed", "domain", domain), true)
+
+	case "disallow":
+		domain := normalizeDomain(subOptMap(sub.Options)["domain"].StringValue())
+		gs.Lock()
+		found := false
+		kept := make([]string, 0, len(gs.LinkFilter.AllowedDomains))
+		for _, d := range gs.LinkFilter.AllowedDomains {
+			if d == domain {
+				found = true
+				continue
+			}
+			kept = append(kept, d)
+		}
+		gs.LinkFilter.AllowedDomains = kept
+		gs.Unlock()
+		if !found {
+			respond(s, i, lang.T("linkfilter_not_allowed", "domain", domain), true)
+			return
+		}
+		_ = gs.Save()
+		respond(s, i, lang.T("linkfilter_disallowed", "domain", domain), true)
+
+	case "status":
+		gs.Lock()
+		lf := gs.LinkFilter
+		domains := strings.Join(lf.AllowedDomains, ", ")
+		gs.Unlock()
+		if domains == "" {
+			domains = "—"
+		}
+		phishingDomainMu.Lock()
+		phishing := len(phishingDomains)
+		phishingDomainMu.Unlock()
+
+		action := lf.Action
+		if lf.Duration != "" && action == "timeout" {
+			action += " " + lf.Duration
+		}
+		respond(s, i, lang.T("linkfilter_status",
+			"enabled", strconv.FormatBool(lf.Enabled),
+			"invites", strconv.FormatBool(lf.BlockInvites),
+			"links", strconv.FormatBool(lf.BlockLinks),
+			"action", action,
+			"domains", domains,
+			"phishing", strconv.Itoa(phishing),
+		), true)
+
+	case "reload":
+		count, err := loadPhishingList(true)
+		if err != nil {
+			respond(s, i, lang.T("linkfilter_reload_failed", "path", storage.Cfg.Moderation.PhishingListPath, "error", err.Error()), true)
+			return
+		}
+		respond(s, i, lang.T("linkfilter_reloaded", "count", strconv.Itoa(count)), true)
+	}
+}
+
+func handleLinkFilterMessage(s *discordgo.Session, m *discordgo.Message) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || m.Content == "" {
+		return
+	}
+
+	gs := storage.GetGuild(m.GuildID)
+	gs.Lock()
+	lf := gs.LinkFilter
+	lf.AllowedDomains = append([]string(nil), lf.AllowedDomains...)
+	gs.Unlock()
+	if !lf.Enabled {
+		return
+	}
+
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	if filterRuleExempt(config.FilterRule{ExemptChannels: lf.ExemptChannels, ExemptRoles: lf.ExemptRoles}, m.ChannelID, roles) {
+		return
+	}
+
+	reason := linkFilterViolation(s, m.GuildID, m.Content, &lf)
+	if reason == "" {
+		return
+	}
+	action := lf.Action
+	if action == "" {
+		action = "delete"
+	}
+	applyAutoModAction(s, m.GuildID, m.ChannelID, m.ID, m.Author, action, lf.Duration, reason)
+}
+
+// linkFilterViolation returns why content breaks the link filter, or "" when it
+// doesn't. Phishing domains are always caught, even when links are allowed.
+func linkFilterViolation(s *discordgo.Session, guildID, content string, lf *config.LinkFilterState) string {
+	if lf.BlockInvites {
+		for _, match := range invitePattern.FindAllStringSubmatch(content, -1) {
+			if inviteGuild(s, match[1]) != guildID {
+				return lang.T("linkfilter_reason_invite")
+			}
+		}
+	}
+
+	_, _ = loadPhishingList(false)
+	for _, raw := range urlPattern.FindAllString(content, -1) {
+		for _, host := range urlHosts(raw) {
+			if isPhishingDomain(host) {
+				return lang.T("linkfilter_reason_phishing", "domain", host)
+			}
+			if lf.BlockLinks && !domainAllowed(host, lf.AllowedDomains) && !isDiscordHost(host) {
+				return lang.T("linkfilter_reason_link", "domain", host)
+			}
+		}
+	}
+	return ""
+}
+
+// urlHosts returns the hostname of a URL plus those of any URLs passed in its
+// query string, so redirect links like google.com/url?q=https://evil.example
+// are judged by where they actually lead.
+func urlHosts(raw string) []string {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	hosts := []string{normalizeDomain(u.Hostname())}
+	for _, values := range u.Query() {
+		for _, v := range values {
+			if urlPattern.MatchString(v) {
+				for _, nested := range urlPattern.FindAllString(v, -1) {
+					hosts = append(hosts, urlHosts(nested)...)
+				}
+			}
+		}
+	}
+	return hosts
+}
+
+func normalizeDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	d = strings.TrimPrefix(d, "http://")
+	d = strings.TrimPrefix(d, "https://")
+	if idx := strings.IndexAny(d, "/?#"); idx >= 0 {
+		d = d[:idx]
+	}
+	d = strings.TrimSuffix(d, ".")
+	return strings.TrimPrefix(d, "www.")
+}
+
+// domainMatches reports whether host is domain or one of its subdomains.
+func domainMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func domainAllowed(host string, allowed []string) bool {
+	for _, d := range allowed {
+		if domainMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDiscordHost lets links to Discord itself through; invites are handled separately.
+func isDiscordHost(host string) bool {
+	for _, d := range []string{"discord.com", "discordapp.com", "discord.gg", "discordapp.net"} {
+		if domainMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPhishingDomain(host string) bool {
+	phishingDomainMu.Lock()
+	defer phishingDomainMu.Unlock()
+	for h := host; h != ""; {
+		if phishingDomains[h] {
+			return true
+		}
+		idx := strings.IndexByte(h, '.')
+		if idx < 0 {
+			break
+		}
+		h = h[idx+1:]
+	}
+	return false
+}
+
+// loadPhishingList (re)reads the phishing domain file. Unless force is set it
+// only rereads when the file changed since the last load.
+func loadPhishingList(force bool) (int, error) {
+	phishingDomainMu.Lock()
+	if !force && time.Since(phishingCheckedAt) < phishingCheckEvery {
+		count := len(phishingDomains)
+		phishingDomainMu.Unlock()
+		return count, nil
+	}
+	phishingCheckedAt = time.Now()
+	phishingDomainMu.Unlock()
+
+	path := storage.Cfg.Moderation.PhishingListPath
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	phishingDomainMu.Lock()
+	if !force && info.ModTime().Equal(phishingModTime) {
+		count := len(phishingDomains)
+		phishingDomainMu.Unlock()
+		return count, nil
+	}
+	phishingDomainMu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if d := normalizeDomain(line); d != "" {
+			domains[d] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	phishingDomainMu.Lock()
+	phishingDomains = domains
+	phishingModTime = info.ModTime()
+	phishingDomainMu.Unlock()
+	return len(domains), nil
+}
+
+// inviteGuild resolves an invite code to its guild ID. Unknown or expired
+// invites resolve to "" and are treated as foreign. Other lookup failures
+// aren't cached, so a transient error doesn't stick to the code.
+func inviteGuild(s *discordgo.Session, code string) string {
+	inviteGuildsMu.Lock()
+	if l, ok := inviteGuilds[code]; ok && time.Since(l.at) < inviteCacheTTL {
+		inviteGuildsMu.Unlock()
+		return l.guildID
+	}
+	inviteGuildsMu.Unlock()
+
+	inv, err := s.Invite(code)
+	guildID := ""
+	if err == nil && inv.Guild != nil {
+		guildID = inv.Guild.ID
+	}
+	var restErr *discordgo.RESTError
+	unknown := errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownInvite
+	if err != nil && !unknown {
+		return guildID
+	}
+
+	inviteGuildsMu.Lock()
+	if len(inviteGuilds) >= maxInviteCache {
+		for c, l := range inviteGuilds {
+			if time.Since(l.at) >= inviteCacheTTL {
+				delete(inviteGuilds, c)
+			}
+		}
+		if len(inviteGuilds) >= maxInviteCache {
+			inviteGuilds = make(map[string]inviteLookup)
+		}
+	}
+	inviteGuilds[code] = inviteLookup{guildID: guildID, at: time.Now()}
+	inviteGuildsMu.Unlock()
+	return guildID
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var channelIDPattern = regexp.MustCompile(`\d{17,20}`)
+
+func lockdownCommands() []*discordgo.ApplicationCommand {
+	targetOpts := func(verb string) []*discordgo.ApplicationCommandOption {
+		return []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "channels", Description: "Channels to " + verb + " (mentions or IDs); defaults to every text channel"},
+			{Type: discordgo.ApplicationCommandOptionChannel, Name: "category", Description: "Only " + verb + " channels in this category", ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory}},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason shown in the mod log and notices"},
+		}
+	}
+	startOpts := append(targetOpts("lock"), &discordgo.ApplicationCommandOption{
+		Type: discordgo.ApplicationCommandOptionBoolean, Name: "notice", Description: "Post a notice in each locked channel",
+	})
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "lockdown",
+			Description:              "Lock many channels at once and restore them afterwards",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "start",
+					Description: "Stop @everyone from sending messages",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     startOpts,
+				},
+				{
+					Name:        "end",
+					Description: "Restore the original permissions of locked channels",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     targetOpts("unlock"),
+				},
+			},
+		},
+	}
+}
+
+func handleLockdownCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	switch sub.Name {
+	case "start":
+		handleLockdownStart(s, i, sub.Options)
+	case "end":
+		handleLockdownEnd(s, i, sub.Options)
+	}
+}
+
+func handleLockdownStart(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	reason := optStr(om, "reason", "No reason provided")
+	notice := om["notice"] != nil && om["notice"].BoolValue()
+
+	targets, err := lockdownTargets(s, i.GuildID, om)
+	if err != nil {
+		followup(s, i, lang.T("lockdown_fetch_failed", "error", err.Error()))
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	already := make(map[string]bool, len(gs.Lockdown.Channels))
+	for _, id := range gs.Lockdown.Channels {
+		already[id] = true
+	}
+	gs.Unlock()
+
+	locked := make([]string, 0, len(targets))
+	failed := 0
+	for _, chID := range targets {
+		if already[chID] {
+			continue
+		}
+		if err := lockChannel(s, i.GuildID, chID, lockHolderLockdown); err != nil {
+			log.Printf("[Lockdown] Failed to lock channel %s: %v", chID, err)
+			failed++
+			continue
+		}
+		locked = append(locked, chID)
+	}
+	if len(locked) == 0 {
+		followup(s, i, lang.T("lockdown_nothing_locked", "failed", strconv.Itoa(failed)))
+		return
+	}
+
+	gs.Lock()
+	if !gs.Lockdown.Active {
+		gs.Lockdown = config.LockdownState{
+			Active:    true,
+			Reason:    reason,
+			StartedAt: time.Now().Format(time.RFC3339),
+			ModID:     i.Member.User.ID,
+			Channels:  []string{},
+			Notice:    notice,
+		}
+	}
+	gs.Lockdown.Channels = append(gs.Lockdown.Channels, locked...)
+	gs.Unlock()
+	_ = gs.Save()
+
+	if notice {
+		for _, chID := range locked {
+			_, _ = s.ChannelMessageSendEmbed(chID, &discordgo.MessageEmbed{
+				Title:       lang.T("lockdown_notice_title"),
+				Description: lang.T("lockdown_notice_desc", "reason", reason),
+				Color:       0xED4245,
+			})
+		}
+	}
+
+	logModEvent(s, i.GuildID, lang.T("lockdown_log_start_title"),
+		lang.T("lockdown_log_start", "mod_id", i.Member.User.ID, "count", strconv.Itoa(len(locked)), "reason", reason),
+		0xED4245)
+	followup(s, i, lang.T("lockdown_started", "count", strconv.Itoa(len(locked)), "failed", strconv.Itoa(failed)))
+}
+
+func handleLockdownEnd(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	reason := optStr(om, "reason", "")
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	ld := gs.Lockdown
+	current := append([]string(nil), ld.Channels...)
+	gs.Unlock()
+	if !ld.Active {
+		followup(s, i, lang.T("lockdown_not_active"))
+		return
+	}
+
+	wanted := make(map[string]bool)
+	if _, ok := om["channels"]; ok || om["category"] != nil {
+		targets, err := lockdownTargets(s, i.GuildID, om)
+		if err != nil {
+			followup(s, i, lang.T("lockdown_fetch_failed", "error", err.Error()))
+			return
+		}
+		for _, id := range targets {
+			wanted[id] = true
+		}
+	} else {
+		for _, id := range current {
+			wanted[id] = true
+		}
+	}
+
+	unlocked := make(map[string]bool)
+	failed := 0
+	for _, chID := range current {
+		if !wanted[chID] {
+			continue
+		}
+		if err := unlockChannel(s, i.GuildID, chID, lockHolderLockdown); err != nil {
+			log.Printf("[Lockdown] Failed to unlock channel %s: %v", chID, err)
+			failed++
+			continue
+		}
+		unlocked[chID] = true
+	}
+
+	gs.Lock()
+	kept := make([]string, 0, len(gs.Lockdown.Channels))
+	for _, chID := range gs.Lockdown.Channels {
+		if !unlocked[chID] {
+			kept = append(kept, chID)
+		}
+	}
+	gs.Lockdown.Channels = kept
+	remaining := len(kept)
+	if remaining == 0 {
+		gs.Lockdown = config.LockdownState{Channels: []string{}}
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	if ld.Notice {
+		for chID := range unlocked {
+			_, _ = s.ChannelMessageSendEmbed(chID, &discordgo.MessageEmbed{
+				Title: lang.T("lockdown_lifted_title"),
+				Color: 0x57F287,
+			})
+		}
+	}
+
+	desc := lang.T("lockdown_log_end", "mod_id", i.Member.User.ID, "count", strconv.Itoa(len(unlocked)), "remaining", strconv.Itoa(remaining))
+	if reason != "" {
+		desc += "\n" + lang.T("lockdown_log_reason", "reason", reason)
+	}
+	logModEvent(s, i.GuildID, lang.T("lockdown_log_end_title"), desc, 0x57F287)
+	followup(s, i, lang.T("lockdown_ended",
+		"count", strconv.Itoa(len(unlocked)),
+		"failed", strconv.Itoa(failed),
+		"remaining", strconv.Itoa(remaining),
+	))
+}
+
+// lockdownTargets resolves the channels option, the category option, or
+// falls back to every text and announcement channel in the guild.
+func lockdownTargets(s *discordgo.Session, guildID string, om map[string]*discordgo.ApplicationCommandInteractionDataOption) ([]string, error) {
+	if o, ok := om["channels"]; ok {
+		return channelIDPattern.FindAllString(o.StringValue(), -1), nil
+	}
+
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return nil, err
+	}
+	categoryID := ""
+	if o, ok := om["category"]; ok {
+		categoryID = o.ChannelValue(s).ID
+	}
+
+	ids := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if ch.Type != discordgo.ChannelTypeGuildText && ch.Type != discordgo.ChannelTypeGuildNews {
+			continue
+		}
+		if categoryID != "" && ch.ParentID != categoryID {
+			continue
+		}
+		ids = append(ids, ch.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxMassBan caps how many users one /massban may ban, which keeps a run well
+// inside the 15 minutes an interaction token stays valid.
+const maxMassBan = 500
+
+// maxMassBanFile is the largest ID list /massban downloads.
+const maxMassBanFile = 1 << 20
+
+// massBanProgressEvery is how often the /massban progress message is edited.
+const massBanProgressEvery = 3 * time.Second
+
+// maxMassBanFailures is how many failed IDs the summaries list by name, and
+// maxMassBanCases how many case numbers the mod log summary lists.
+const (
+	maxMassBanFailures = 10
+	maxMassBanCases    = 50
+)
+
+var snowflakePattern = regexp.MustCompile(`\b\d{17,20}\b`)
+
+func massBanCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "softban",
+			Description:              "Ban and immediately unban a member to delete their recent messages",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to softban", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for softban"},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "days", Description: "Days of messages to delete (1-7, default 1)"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't DM the user about this"},
+			},
+		},
+		{
+			Name:                     "massban",
+			Description:              "Ban many users by ID at once",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "ids", Description: "User IDs separated by spaces, commas or new lines"},
+				{Type: discordgo.ApplicationCommandOptionAttachment, Name: "file", Description: "Text file of user IDs"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for the bans"},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "days", Description: "Days of messages to delete (0-7)"},
+			},
+		},
+	}
+}
+
+func handleSoftban(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionMap(i)
+	target := opts["user"].UserValue(s)
+	reason := optStr(opts, "reason", "No reason provided")
+	days := int(optInt(opts, "days", 1))
+	if days < 1 {
+		days = 1
+	}
+	if days > 7 {
+		days = 7
+	}
+
+	// The checks, DM, ban and unban easily take longer than three seconds.
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+
+	notice := notifyTarget(s, i.GuildID, target, "softban", reason, "", optBool(opts, "silent", false))
+	if err := s.GuildBanCreateWithReason(i.GuildID, target.ID, reason, days); err != nil {
+		notice.retract(s)
+		followup(s, i, lang.T("mod_softban_failed", "error", err.Error()))
+		return
+	}
+	if err := s.GuildBanDelete(i.GuildID, target.ID); err != nil {
+		// The messages are gone but the user is still banned; record and
+		// announce it as the ban it now is, with the appeal button.
+		followup(s, i, lang.T("mod_softban_unban_failed", "user", target.Username, "error", err.Error()))
+		notice.action = "ban"
+		caseID := logModActionExtra(s, i.GuildID, "Ban", target, i.Member.User, reason, "", notice.logField())
+		notice.attachCase(s, i.GuildID, caseID)
+		return
+	}
+
+	followup(s, i, lang.T("mod_softban_success", "user", target.Username, "days", strconv.Itoa(days), "reason", reason))
+	caseID := logModActionExtra(s, i.GuildID, "Softban", target, i.Member.User, reason, "", notice.logField())
+	notice.attachCase(s, i.GuildID, caseID)
+}
+
+func handleMassban(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionMap(i)
+	reason := optStr(opts, "reason", "No reason provided")
+	days := int(optInt(opts, "days", 0))
+	if days < 0 {
+		days = 0
+	}
+	if days > 7 {
+		days = 7
+	}
+
+	deferEphemeral(s, i)
+
+	input := optStr(opts, "ids", "")
+	if opt, ok := opts["file"]; ok {
+		text, err := massBanFile(i, opt)
+		if err != nil {
+			followup(s, i, lang.T("mod_massban_file_failed", "error", err.Error()))
+			return
+		}
+		input += "\n" + text
+	}
+	ids := parseUserIDs(input)
+	if len(ids) == 0 {
+		followup(s, i, lang.T("mod_massban_no_ids"))
+		return
+	}
+	if len(ids) > maxMassBan {
+		followup(s, i, lang.T("mod_massban_too_many", "count", strconv.Itoa(len(ids)), "max", strconv.Itoa(maxMassBan)))
+		return
+	}
+
+	tc, problem := newTargetCheck(s, i)
+	if problem != "" {
+		followup(s, i, problem)
+		return
+	}
+
+	alreadyBanned, err := guildBanSet(s, i.GuildID)
+	if err != nil {
+		followup(s, i, lang.T("mod_massban_bans_failed", "error", err.Error()))
+		return
+	}
+
+	mod := i.Member.User
+	banned, skipped := 0, 0
+	var caseIDs []string
+	var failed []string
+	lastEdit := time.Now()
+	progress := func(waiting time.Duration) {
+		text := lang.T("mod_massban_progress",
+			"done", strconv.Itoa(banned+skipped+len(failed)), "total", strconv.Itoa(len(ids)),
+			"banned", strconv.Itoa(banned), "skipped", strconv.Itoa(skipped), "failed", strconv.Itoa(len(failed)))
+		if waiting > 0 {
+			text += "\n" + lang.T("mod_massban_rate_limited", "seconds", fmt.Sprintf("%.1f", waiting.Seconds()))
+		}
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &text})
+		lastEdit = time.Now()
+	}
+	progress(0)
+
+	for _, id := range ids {
+		if alreadyBanned[id] {
+			skipped++
+			continue
+		}
+		if problem := tc.check(id); problem != "" {
+			failed = append(failed, fmt.Sprintf("`%s` — %s", id, problem))
+			continue
+		}
+		if err := banWithBackoff(s, i.GuildID, id, reason, days, progress); err != nil {
+			failed = append(failed, fmt.Sprintf("`%s` — %s", id, restErrorMessage(err)))
+			continue
+		}
+		banned++
+		if caseID := recordModCase(i.GuildID, "Ban", id, mod.ID, reason, ""); caseID > 0 {
+			caseIDs = append(caseIDs, "#"+strconv.Itoa(caseID))
+		}
+		if time.Since(lastEdit) >= massBanProgressEvery {
+			progress(0)
+		}
+	}
+
+	summary := lang.T("mod_massban_log",
+		"mod_id", mod.ID, "banned", strconv.Itoa(banned), "total", strconv.Itoa(len(ids)),
+		"skipped", strconv.Itoa(skipped), "failed", strconv.Itoa(len(failed)), "reason", reason)
+	if len(caseIDs) > 0 {
+		summary += "\n" + formatMassBanCases(caseIDs)
+	}
+	if len(failed) > 0 {
+		summary += "\n\n" + formatMassBanFailures(failed)
+	}
+	logModEvent(s, i.GuildID, lang.T("mod_massban_log_title"), summary, 0xED4245)
+
+	done := lang.T("mod_massban_done", "banned", strconv.Itoa(banned), "total", strconv.Itoa(len(ids)),
+		"skipped", strconv.Itoa(skipped), "failed", strconv.Itoa(len(failed)))
+	if len(failed) > 0 {
+		done += "\n\n" + formatMassBanFailures(failed)
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &done})
+}
+
+// guildBanSet returns the IDs of everyone already banned from the guild, so
+// /massban doesn't ban them again or open duplicate cases.
+func guildBanSet(s *discordgo.Session, guildID string) (map[string]bool, error) {
+	banned := make(map[string]bool)
+	after := ""
+	for {
+		bans, err := s.GuildBans(guildID, 1000, "", after)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bans {
+			if b.User != nil {
+				banned[b.User.ID] = true
+			}
+		}
+		if len(bans) < 1000 {
+			return banned, nil
+		}
+		after = bans[len(bans)-1].User.ID
+	}
+}
+
+// formatMassBanCases lists the case numbers a mass ban opened. Other cases can
+// be written while it runs, so they aren't shown as a range.
+func formatMassBanCases(caseIDs []string) string {
+	shown := caseIDs
+	if len(shown) > maxMassBanCases {
+		shown = shown[:maxMassBanCases]
+	}
+	text := lang.T("mod_massban_cases", "count", strconv.Itoa(len(caseIDs)), "cases", strings.Join(shown, ", "))
+	if len(caseIDs) > len(shown) {
+		text += " " + lang.T("mod_massban_more_failures", "count", strconv.Itoa(len(caseIDs)-len(shown)))
+	}
+	return text
+}
+
+// banWithBackoff bans userID, waiting out rate limits itself instead of letting
+// the session sleep silently, so the progress message can say why it paused.
+func banWithBackoff(s *discordgo.Session, guildID, userID, reason string, days int, progress func(time.Duration)) error {
+	for attempt := 0; ; attempt++ {
+		err := s.GuildBanCreateWithReason(guildID, userID, reason, days, discordgo.WithRetryOnRatelimit(false))
+		var rl *discordgo.RateLimitError
+		if !errors.As(err, &rl) || attempt == 3 {
+			return err
+		}
+		progress(rl.RetryAfter)
+		time.Sleep(rl.RetryAfter)
+	}
+}
+
+// restErrorMessage keeps just Discord's message from a REST error, which is
+// short enough to list many of.
+func restErrorMessage(err error) string {
+	var rest *discordgo.RESTError
+	if errors.As(err, &rest) && rest.Message != nil && rest.Message.Message != "" {
+		return rest.Message.Message
+	}
+	return err.Error()
+}
+
+// massBanFile downloads the text file attached to /massban.
+func massBanFile(i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
+	data := i.ApplicationCommandData()
+	if data.Resolved == nil {
+		return "", errors.New("attachment not found")
+	}
+	att := data.Resolved.Attachments[opt.Value.(string)]
+	if att == nil {
+		return "", errors.New("attachment not found")
+	}
+	if att.Size > maxMassBanFile {
+		return "", fmt.Errorf("file is larger than %d KB", maxMassBanFile/1024)
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(att.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMassBanFile))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// parseUserIDs returns every distinct snowflake in text, in order.
+func parseUserIDs(text string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range snowflakePattern.FindAllString(text, -1) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func formatMassBanFailures(failed []string) string {
+	shown := failed
+	if len(shown) > maxMassBanFailures {
+		shown = shown[:maxMassBanFailures]
+	}
+	text := lang.T("mod_massban_failures") + "\n" + strings.Join(shown, "\n")
+	if len(failed) > len(shown) {
+		text += "\n" + lang.T("mod_massban_more_failures", "count", strconv.Itoa(len(failed)-len(shown)))
+	}
+	return text
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,7 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to ban", Required: true},
 				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for ban"},
 				{Type: discordgo.ApplicationCommandOptionInteger, Name: "days", Description: "Days of messages to delete (0-7)"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't DM the user about this"},
 			},
 		},
 		{
@@ -44,6 +46,7 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 			Options: []*discordgo.ApplicationCommandOption{
 				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to kick", Required: true},
 				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for kick"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't DM the user about this"},
 			},
 		},
 		{
@@ -54,6 +57,7 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to mute", Required: true},
 				{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Duration (e.g. 10m, 1h, 1d)", Required: true},
 				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for mute"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't DM the user about this"},
 			},
 		},
 		{
@@ -71,6 +75,7 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 			Options: []*discordgo.ApplicationCommandOption{
 				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User to warn", Required: true},
 				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for warning", Required: true},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't DM the user about this"},
 			},
 		},
 		{
@@ -94,8 +99,17 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 			Description:              "Delete a number of messages from the channel",
 			DefaultMemberPermissions: &modPermission,
 			Options: []*discordgo.ApplicationCommandOption{
-				{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "Number of messages to delete (1-100)", Required: true},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "Number of messages to scan (1-5000)", Required: true},
 				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Only delete messages from this user"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "bots", Description: "Only delete messages from bots"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "attachments", Description: "Only delete messages with attachments"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "links", Description: "Only delete messages containing links"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "embeds", Description: "Only delete messages with embeds"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "contains", Description: "Only delete messages containing this text"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "regex", Description: "Only delete messages matching this regular expression"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "before", Description: "Only scan messages before this message ID"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "after", Description: "Only scan messages after this message ID"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "include_pinned", Description: "Also delete pinned messages (skipped by default)"},
 			},
 		},
 		{
@@ -103,7 +117,7 @@ func moderationCommands() []*discordgo.ApplicationCommand {
 			Description:              "Clear a number of messages from the channel",
 			DefaultMemberPermissions: &modPermission,
 			Options: []*discordgo.ApplicationCommandOption{
-				{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "Number of messages to delete (1-100)", Required: true},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "Number of messages to delete (1-5000)", Required: true},
 			},
 		},
 		{
@@ -152,14 +166,24 @@ func handleBan(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		days = 7
 	}
 
+	// The checks, DM and ban easily take longer than three seconds.
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+
+	notice := notifyTarget(s, i.GuildID, target, "ban", reason, "", optBool(opts, "silent", false))
 	err := s.GuildBanCreateWithReason(i.GuildID, target.ID, reason, days)
 	if err != nil {
-		respond(s, i, lang.T("mod_ban_failed", "error", err.Error()), true)
+		notice.retract(s)
+		followup(s, i, lang.T("mod_ban_failed", "error", err.Error()))
 		return
 	}
 
-	respond(s, i, lang.T("mod_ban_success", "user", target.Username, "reason", reason), false)
-	logModAction(s, i.GuildID, "Ban", target, i.Member.User, reason, "")
+	followupPublic(s, i, lang.T("mod_ban_success", "user", target.Username, "reason", reason))
+	caseID := logModActionExtra(s, i.GuildID, "Ban", target, i.Member.User, reason, "", notice.logField())
+	notice.attachCase(s, i.GuildID, caseID)
 }
 
 func handleUnban(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -181,14 +205,23 @@ func handleKick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	target := opts["user"].UserValue(s)
 	reason := optStr(opts, "reason", "No reason provided")
 
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+
+	notice := notifyTarget(s, i.GuildID, target, "kick", reason, "", optBool(opts, "silent", false))
 	err := s.GuildMemberDeleteWithReason(i.GuildID, target.ID, reason)
 	if err != nil {
-		respond(s, i, lang.T("mod_kick_failed", "error", err.Error()), true)
+		notice.retract(s)
+		followup(s, i, lang.T("mod_kick_failed", "error", err.Error()))
 		return
 	}
 
-	respond(s, i, lang.T("mod_kick_success", "user", target.Username, "reason", reason), false)
-	logModAction(s, i.GuildID, "Kick", target, i.Member.User, reason, "")
+	followupPublic(s, i, lang.T("mod_kick_success", "user", target.Username, "reason", reason))
+	caseID := logModActionExtra(s, i.GuildID, "Kick", target, i.Member.User, reason, "", notice.logField())
+	notice.attachCase(s, i.GuildID, caseID)
 }
 
 func handleMute(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -207,24 +240,45 @@ func handleMute(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	until := time.Now().Add(dur)
-	err = s.GuildMemberTimeout(i.GuildID, target.ID, &until)
-	if err != nil {
-		respond(s, i, lang.T("mod_mute_failed", "error", err.Error()), true)
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+	if err := timeoutMember(s, i.GuildID, target, i.Member.User, dur, durStr, reason, optBool(opts, "silent", false)); err != nil {
+		followup(s, i, lang.T("mod_mute_failed", "error", err.Error()))
 		return
 	}
+	followupPublic(s, i, lang.T("mod_mute_success", "user", target.Username, "duration", durStr, "reason", reason))
+}
 
-	respond(s, i, lang.T("mod_mute_success", "user", target.Username, "duration", durStr, "reason", reason), false)
-	logModAction(s, i.GuildID, "Mute", target, i.Member.User, reason, durStr)
+// timeoutMember notifies target, times them out for dur, records the case and
+// syncs the mute to Minecraft. Shared by /mute and the other moderation entry
+// points (reports, context menus).
+func timeoutMember(s *discordgo.Session, guildID string, target, mod *discordgo.User, dur time.Duration, durStr, reason string, silent bool) error {
+	until := time.Now().Add(dur)
+	notice := notifyTarget(s, guildID, target, "mute", reason, durStr, silent)
+	if err := s.GuildMemberTimeout(guildID, target.ID, &until); err != nil {
+		notice.retract(s)
+		return err
+	}
+
+	caseID := logModActionExtra(s, guildID, "Mute", target, mod, reason, durStr, notice.logField())
+	notice.attachCase(s, guildID, caseID)
 
 	if ActiveBridge != nil {
-		ActiveBridge.SyncMuteToMC(target.ID, until, reason, i.Member.User.Username)
+		ActiveBridge.SyncMuteToMC(target.ID, until, reason, mod.Username)
 	}
+	return nil
 }
 
 func handleUnmute(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	opts := optionMap(i)
 	target := opts["user"].UserValue(s)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		respond(s, i, problem, true)
+		return
+	}
 
 	err := s.GuildMemberTimeout(i.GuildID, target.ID, nil)
 	if err != nil {
@@ -245,26 +299,46 @@ func handleWarn(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	target := opts["user"].UserValue(s)
 	reason := opts["reason"].StringValue()
 
+	deferEphemeral(s, i)
+	if problem := checkModTarget(s, i, target.ID); problem != "" {
+		followup(s, i, problem)
+		return
+	}
+	w := warnMember(s, i.GuildID, target, i.Member.User, reason, optBool(opts, "silent", false))
+	followupPublic(s, i, lang.T("mod_warn_success", "user", target.Username, "id", strconv.Itoa(w.ID), "reason", reason))
+}
+
+// warnMember notifies target, records the warning and logs the mod case.
+func warnMember(s *discordgo.Session, guildID string, target, mod *discordgo.User, reason string, silent bool) config.Warning {
+	notice := notifyTarget(s, guildID, target, "warn", reason, "", silent)
+	w := addWarning(guildID, target.ID, mod.ID, reason)
+
+	caseID := logModActionExtra(s, guildID, fmt.Sprintf("Warn (#%d)", w.ID), target, mod, reason, "", notice.logField())
+	notice.attachCase(s, guildID, caseID)
+	return w
+}
+
+// addWarning records a warning in the database and the guild state and returns
+// it with its per-user number filled in.
+func addWarning(guildID, userID, modID, reason string) config.Warning {
 	w := config.Warning{
 		Reason:    reason,
-		ModID:     i.Member.User.ID,
+		ModID:     modID,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
 	if storage.DB != nil {
-		_ = storage.DB.AddWarning(i.GuildID, target.ID, w)
+		_ = storage.DB.AddWarning(guildID, userID, w)
 	}
 
-	gs := storage.GetGuild(i.GuildID)
+	gs := storage.GetGuild(guildID)
 	gs.Lock()
-	warns := gs.Warnings[target.ID]
+	warns := gs.Warnings[userID]
 	w.ID = len(warns) + 1
-	gs.Warnings[target.ID] = append(warns, w)
+	gs.Warnings[userID] = append(warns, w)
 	gs.Unlock()
 	_ = gs.Save()
-
-	respond(s, i, lang.T("mod_warn_success", "user", target.Username, "id", strconv.Itoa(w.ID), "reason", reason), false)
-	logModAction(s, i.GuildID, fmt.Sprintf("Warn (#%d)", w.ID), target, i.Member.User, reason, "")
+	return w
 }
 
 func handleWarnings(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -319,52 +393,6 @@ func handleClearWarnings(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	respond(s, i, lang.T("mod_warnings_cleared", "user", target.Username), false)
 }
 
-func handlePurge(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	opts := optionMap(i)
-	count := int(opts["count"].IntValue())
-	if count < 1 || count > 100 {
-		respond(s, i, lang.T("mod_purge_invalid_count"), true)
-		return
-	}
-
-	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
-	})
-
-	msgs, err := s.ChannelMessages(i.ChannelID, count, "", "", "")
-	if err != nil {
-		followup(s, i, lang.T("mod_purge_fetch_failed", "error", err.Error()))
-		return
-	}
-
-	var filterUser *discordgo.User
-	if u, ok := opts["user"]; ok {
-		filterUser = u.UserValue(s)
-	}
-
-	ids := make([]string, 0, len(msgs))
-	for _, m := range msgs {
-		if filterUser != nil && m.Author.ID != filterUser.ID {
-			continue
-		}
-		ids = append(ids, m.ID)
-	}
-
-	if len(ids) == 0 {
-		followup(s, i, lang.T("mod_purge_no_messages"))
-		return
-	}
-
-	if len(ids) == 1 {
-		_ = s.ChannelMessageDelete(i.ChannelID, ids[0])
-	} else {
-		_ = s.ChannelMessagesBulkDelete(i.ChannelID, ids)
-	}
-
-	followup(s, i, lang.T("mod_purge_success", "count", strconv.Itoa(len(ids))))
-}
-
 func handleSlowmode(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	opts := optionMap(i)
 	secs := int(opts["seconds"].IntValue())
@@ -389,12 +417,7 @@ func handleSlowmode(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func handleLock(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.ChannelPermissionSet(
-		i.ChannelID, i.GuildID,
-		discordgo.PermissionOverwriteTypeRole,
-		0, discordgo.PermissionSendMessages,
-	)
-	if err != nil {
+	if err := lockChannel(s, i.GuildID, i.ChannelID, lockHolderManual); err != nil {
 		respond(s, i, lang.T("mod_lock_failed", "error", err.Error()), true)
 		return
 	}
@@ -402,18 +425,221 @@ func handleLock(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func handleUnlock(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.ChannelPermissionSet(
-		i.ChannelID, i.GuildID,
-		discordgo.PermissionOverwriteTypeRole,
-		discordgo.PermissionSendMessages, 0,
-	)
-	if err != nil {
+	if err := unlockChannel(s, i.GuildID, i.ChannelID, ""); err != nil {
 		respond(s, i, lang.T("mod_unlock_failed", "error", err.Error()), true)
 		return
 	}
 	respond(s, i, lang.T("mod_unlock_success"), false)
 }
 
+// Features that can hold a channel lock. /lock and /unlock use lockHolderManual
+// and the empty holder respectively.
+const (
+	lockHolderManual   = "manual"
+	lockHolderLockdown = "lockdown"
+	lockHolderRaid     = "raid"
+)
+
+// lockChannel denies SendMessages to @everyone (whose role ID is the guild ID)
+// while keeping the rest of the overwrite. The original overwrite is saved the
+// first time a channel is locked so unlockChannel can restore it exactly, and
+// holder is recorded so one feature ending doesn't unlock another's channels.
+func lockChannel(s *discordgo.Session, guildID, channelID, holder string) error {
+	snap, err := everyoneOverwrite(s, guildID, channelID)
+	if err != nil {
+		return err
+	}
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	saved, locked := gs.ChannelLocks[channelID]
+	if !locked {
+		saved = snap
+	}
+	held := slices.Contains(saved.Holders, holder)
+	if !held {
+		saved.Holders = append(saved.Holders, holder)
+	}
+	gs.ChannelLocks[channelID] = saved
+	gs.Unlock()
+	_ = gs.Save()
+
+	err = s.ChannelPermissionSet(
+		channelID, guildID,
+		discordgo.PermissionOverwriteTypeRole,
+		snap.Allow&^discordgo.PermissionSendMessages, snap.Deny|discordgo.PermissionSendMessages,
+	)
+	if err != nil && !held {
+		// The channel isn't locked, so don't leave it recorded as held.
+		gs.Lock()
+		if !locked {
+			delete(gs.ChannelLocks, channelID)
+		} else if cur, ok := gs.ChannelLocks[channelID]; ok {
+			cur.Holders = slices.DeleteFunc(slices.Clone(cur.Holders), func(h string) bool { return h == holder })
+			gs.ChannelLocks[channelID] = cur
+		}
+		gs.Unlock()
+		_ = gs.Save()
+	}
+	return err
+}
+
+// unlockChannel releases holder's lock on a channel and puts back the
+// @everyone overwrite saved by lockChannel once no other feature holds it. An
+// empty holder (/unlock) releases every lock. Channels locked before snapshots
+// existed just have the SendMessages deny lifted on /unlock.
+func unlockChannel(s *discordgo.Session, guildID, channelID, holder string) error {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	snap, ok := gs.ChannelLocks[channelID]
+	if ok && holder != "" {
+		remaining := slices.DeleteFunc(slices.Clone(snap.Holders), func(h string) bool { return h == holder })
+		if len(remaining) > 0 {
+			snap.Holders = remaining
+			gs.ChannelLocks[channelID] = snap
+			gs.Unlock()
+			_ = gs.Save()
+			return nil
+		}
+	}
+	gs.Unlock()
+
+	if !ok && holder != "" {
+		return nil // already unlocked, e.g. by /unlock
+	}
+	if !ok {
+		cur, err := everyoneOverwrite(s, guildID, channelID)
+		if err != nil {
+			return err
+		}
+		snap = cur
+		snap.Deny &^= discordgo.PermissionSendMessages
+	}
+
+	var err error
+	if snap.Existed || snap.Allow != 0 || snap.Deny != 0 {
+		err = s.ChannelPermissionSet(channelID, guildID, discordgo.PermissionOverwriteTypeRole, snap.Allow, snap.Deny)
+	} else {
+		err = s.ChannelPermissionDelete(channelID, guildID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		gs.Lock()
+		delete(gs.ChannelLocks, channelID)
+		gs.Unlock()
+		_ = gs.Save()
+	}
+	return nil
+}
+
+// everyoneOverwrite reads a channel's current @everyone overwrite.
+func everyoneOverwrite(s *discordgo.Session, guildID, channelID string) (config.ChannelOverwriteSnapshot, error) {
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		if ch, err = s.Channel(channelID); err != nil {
+			return config.ChannelOverwriteSnapshot{}, err
+		}
+	}
+	for _, ow := range ch.PermissionOverwrites {
+		if ow.ID == guildID && ow.Type == discordgo.PermissionOverwriteTypeRole {
+			return config.ChannelOverwriteSnapshot{Existed: true, Allow: ow.Allow, Deny: ow.Deny}, nil
+		}
+	}
+	return config.ChannelOverwriteSnapshot{}, nil
+}
+
+// checkModTarget stops moderators from acting on themselves, the bot, the
+// server owner, or anyone whose highest role is not below both their own and
+// the bot's. It returns a localized error, or "" when the action may proceed.
+// Users who aren't members (e.g. banning by ID) only get the identity checks.
+func checkModTarget(s *discordgo.Session, i *discordgo.InteractionCreate, targetID string) string {
+	tc, problem := newTargetCheck(s, i)
+	if problem != "" {
+		return problem
+	}
+	return tc.check(targetID)
+}
+
+// targetCheck holds what checkModTarget needs to know about the guild, so
+// commands acting on many users fetch it once.
+type targetCheck struct {
+	s         *discordgo.Session
+	guildID   string
+	modID     string
+	ownerID   string
+	modTop    int
+	botTop    int
+	positions map[string]int
+}
+
+func newTargetCheck(s *discordgo.Session, i *discordgo.InteractionCreate) (*targetCheck, string) {
+	guild, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		if guild, err = s.Guild(i.GuildID); err != nil {
+			return nil, lang.T("mod_target_check_failed", "error", err.Error())
+		}
+	}
+	botMember, err := s.GuildMember(i.GuildID, s.State.User.ID)
+	if err != nil {
+		return nil, lang.T("mod_target_check_failed", "error", err.Error())
+	}
+	roles, err := s.GuildRoles(i.GuildID)
+	if err != nil {
+		return nil, lang.T("mod_target_check_failed", "error", err.Error())
+	}
+
+	tc := &targetCheck{
+		s:         s,
+		guildID:   i.GuildID,
+		modID:     i.Member.User.ID,
+		ownerID:   guild.OwnerID,
+		positions: make(map[string]int, len(roles)),
+	}
+	for _, r := range roles {
+		tc.positions[r.ID] = r.Position
+	}
+	tc.modTop = tc.highest(i.Member.Roles)
+	tc.botTop = tc.highest(botMember.Roles)
+	return tc, ""
+}
+
+func (tc *targetCheck) highest(roleIDs []string) int {
+	top := 0
+	for _, id := range roleIDs {
+		if p := tc.positions[id]; p > top {
+			top = p
+		}
+	}
+	return top
+}
+
+func (tc *targetCheck) check(targetID string) string {
+	switch targetID {
+	case tc.modID:
+		return lang.T("mod_target_self")
+	case tc.s.State.User.ID:
+		return lang.T("mod_target_bot")
+	case tc.ownerID:
+		return lang.T("mod_target_owner")
+	}
+
+	target, err := cachedMember(tc.s, tc.guildID, targetID)
+	if err != nil {
+		return ""
+	}
+	targetPos := tc.highest(target.Roles)
+	if tc.modID != tc.ownerID && tc.modTop <= targetPos {
+		return lang.T("mod_target_above_you", "user_id", targetID)
+	}
+	if tc.botTop <= targetPos {
+		return lang.T("mod_target_above_bot", "user_id", targetID)
+	}
+	return ""
+}
+
 func handleModlog(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	opts := optionMap(i)
 	ch := opts["channel"].ChannelValue(s)
@@ -432,6 +658,8 @@ func handleUserinfo(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	var target *discordgo.User
 	if u, ok := opts["user"]; ok {
 		target = u.UserValue(s)
+	} else if u := contextMenuUser(i); u != nil {
+		target = u
 	} else {
 		target = i.Member.User
 	}
@@ -467,6 +695,12 @@ func handleUserinfo(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		gs := storage.GetGuild(i.GuildID)
 		warnCount = len(gs.Warnings[target.ID])
 	}
+	noteCount := 0
+	if storage.DB != nil {
+		if n, err := storage.DB.GetNotes(i.GuildID, target.ID); err == nil {
+			noteCount = len(n)
+		}
+	}
 
 	embed := &discordgo.MessageEmbed{
 		Title: lang.T("userinfo_title", "user", target.Username),
@@ -480,29 +714,28 @@ func handleUserinfo(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			{Name: "Joined Server", Value: joinedAt, Inline: true},
 			{Name: lang.T("userinfo_roles_field", "count", strconv.Itoa(len(member.Roles))), Value: roles},
 			{Name: "Warnings", Value: strconv.Itoa(warnCount), Inline: true},
+			{Name: "Notes", Value: strconv.Itoa(noteCount), Inline: true},
 		},
 	}
 
 	respondEmbed(s, i, embed, true)
 }
 
-func logModAction(s *discordgo.Session, guildID, action string, target, moderator *discordgo.User, reason, duration string) {
-	if storage.DB != nil {
-		_ = storage.DB.AddModCase(guildID, storage.ModCase{
-			GuildID:   guildID,
-			UserID:    target.ID,
-			ModID:     moderator.ID,
-			Action:    action,
-			Reason:    reason,
-			Duration:  duration,
-			Timestamp: time.Now().Format(time.RFC3339),
-		})
-	}
+// logModAction records a mod case and posts it to the mod log. It returns the
+// case number, or 0 when no database is configured or the insert failed.
+func logModAction(s *discordgo.Session, guildID, action string, target, moderator *discordgo.User, reason, duration string) int {
+	return logModActionExtra(s, guildID, action, target, moderator, reason, duration)
+}
+
+// logModActionExtra is logModAction with additional fields on the mod log
+// embed, such as whether the target was notified.
+func logModActionExtra(s *discordgo.Session, guildID, action string, target, moderator *discordgo.User, reason, duration string, extra ...*discordgo.MessageEmbedField) int {
+	caseID := recordModCase(guildID, action, target.ID, moderator.ID, reason, duration)
 
 	gs := storage.GetGuild(guildID)
 	logCh := config.EffectiveModLogChannel(storage.Cfg, gs)
 	if logCh == "" {
-		return
+		return caseID
 	}
 
 	embed := &discordgo.MessageEmbed{
@@ -535,8 +768,48 @@ func logModAction(s *discordgo.Session, guildID, action string, target, moderato
 			Inline: true,
 		})
 	}
+	embed.Fields = append(embed.Fields, extra...)
+	if caseID > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: lang.T("modlog_case_footer", "id", strconv.Itoa(caseID))}
+	}
 
 	_, _ = s.ChannelMessageSendEmbed(logCh, embed)
+	return caseID
+}
+
+// recordModCase stores a mod case without posting it to the mod log, for bulk
+// actions that report a single summary instead. It returns the case number, or
+// 0 when no database is configured or the insert failed.
+func recordModCase(guildID, action, userID, modID, reason, duration string) int {
+	if storage.DB == nil {
+		return 0
+	}
+	caseID, _ := storage.DB.AddModCase(guildID, storage.ModCase{
+		GuildID:   guildID,
+		UserID:    userID,
+		ModID:     modID,
+		Action:    action,
+		Reason:    reason,
+		Duration:  duration,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	return caseID
+}
+
+// logModEvent posts an informational embed to the mod log without recording a
+// moderation case, for automated outcomes that aren't actions against a user.
+func logModEvent(s *discordgo.Session, guildID, title, description string, color int) {
+	gs := storage.GetGuild(guildID)
+	logCh := config.EffectiveModLogChannel(storage.Cfg, gs)
+	if logCh == "" {
+		return
+	}
+	_, _ = s.ChannelMessageSendEmbed(logCh, &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
 }
 
 func parseDuration(s string) (time.Duration, error) {
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"discord-bot/lang"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// caseNotice is the DM a member gets about a moderation action. It is sent
+// before the action because a kicked or banned user no longer shares a server
+// with the bot; the case number is edited in once the case has been recorded.
+type caseNotice struct {
+	msg       *discordgo.Message
+	silent    bool
+	guildName string
+	action    string // "ban", "softban", "kick", "mute" or "warn"
+	reason    string
+	duration  string
+}
+
+// notifyTarget DMs target about action unless silent is set. Delivery failures
+// (usually closed DMs) are kept for the mod log rather than reported as errors.
+func notifyTarget(s *discordgo.Session, guildID string, target *discordgo.User, action, reason, duration string, silent bool) *caseNotice {
+	n := &caseNotice{silent: silent, guildName: guildID, action: action, reason: reason, duration: duration}
+	if silent {
+		return n
+	}
+	if g, err := s.State.Guild(guildID); err == nil {
+		n.guildName = g.Name
+	}
+
+	ch, err := s.UserChannelCreate(target.ID)
+	if err != nil {
+		return n
+	}
+	n.msg, err = s.ChannelMessageSendEmbed(ch.ID, n.embed(lang.T("mod_dm_case_pending")))
+	if err != nil {
+		n.msg = nil
+	}
+	return n
+}
+
+func (n *caseNotice) embed(caseNum string) *discordgo.MessageEmbed {
+	duration := n.duration
+	if duration == "" {
+		duration = "—"
+	}
+	return &discordgo.MessageEmbed{
+		Title: lang.T("mod_dm_title", "server", n.guildName),
+		Description: lang.T("mod_dm_notice",
+			"server", n.guildName,
+			"action", lang.T("mod_dm_action_"+n.action),
+			"reason", n.reason,
+			"duration", duration,
+			"case", caseNum,
+		),
+		Color:     0xED4245,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// retract deletes the DM when the action it announced failed.
+func (n *caseNotice) retract(s *discordgo.Session) {
+	if n.msg != nil {
+		_ = s.ChannelMessageDelete(n.msg.ChannelID, n.msg.ID)
+	}
+}
+
+// logField describes the DM delivery for the mod log entry.
+func (n *caseNotice) logField() *discordgo.MessageEmbedField {
+	value := lang.T("mod_dm_sent")
+	switch {
+	case n.silent:
+		value = lang.T("mod_dm_silent")
+	case n.msg == nil:
+		value = lang.T("mod_dm_failed")
+	}
+	return &discordgo.MessageEmbedField{Name: lang.T("mod_dm_field"), Value: value, Inline: true}
+}
+
+// attachCase edits the case number into the DM and, for bans and mutes, the
+// appeal button.
+func (n *caseNotice) attachCase(s *discordgo.Session, guildID string, caseID int) {
+	if n.msg == nil || caseID == 0 {
+		return
+	}
+	embed := n.embed("#" + strconv.Itoa(caseID))
+	components := []discordgo.MessageComponent{}
+	if n.action == "ban" || n.action == "mute" {
+		if c := appealComponents(guildID, caseID); c != nil {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: lang.T("appeal_dm_footer")}
+			components = c
+		}
+	}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    n.msg.ChannelID,
+		ID:         n.msg.ID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("[Moderation] Failed to add case #%d to DM: %v", caseID, err)
+	}
+}
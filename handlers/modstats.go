@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// modStatsPeriods maps the /modstats period choices to their length; "all"
+// has none.
+var modStatsPeriods = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// maxModStatsRows caps how many moderators the guild-wide summary lists.
+const maxModStatsRows = 15
+
+func modStatsCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "modstats",
+			Description:              "Show moderation activity per moderator",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "moderator", Description: "Only show this moderator", Required: false},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "Time range (default: 30 days)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Last 7 days", Value: "7d"},
+						{Name: "Last 30 days", Value: "30d"},
+						{Name: "Last 90 days", Value: "90d"},
+						{Name: "All time", Value: "all"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func handleModStatsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	if storage.DB == nil {
+		respond(s, i, lang.T("modstats_no_database"), true)
+		return
+	}
+	om := optionMap(i)
+	period := optStr(om, "period", "30d")
+
+	var since time.Time
+	if d, ok := modStatsPeriods[period]; ok {
+		since = time.Now().Add(-d)
+	}
+	var mod *discordgo.User
+	modID := ""
+	if opt, ok := om["moderator"]; ok {
+		mod = opt.UserValue(s)
+		modID = mod.ID
+	}
+
+	counts, err := storage.DB.CountModActions(i.GuildID, modID, since)
+	if err != nil {
+		respond(s, i, lang.T("modstats_failed", "error", err.Error()), true)
+		return
+	}
+
+	periodLabel := lang.T("modstats_period_" + period)
+	if len(counts) == 0 {
+		respond(s, i, lang.T("modstats_none", "period", periodLabel), true)
+		return
+	}
+
+	if mod != nil {
+		respondEmbed(s, i, modStatsUserEmbed(mod, periodLabel, counts), true)
+		return
+	}
+	respondEmbed(s, i, modStatsGuildEmbed(periodLabel, counts), true)
+}
+
+// modStatsUserEmbed breaks one moderator's cases down by action.
+func modStatsUserEmbed(mod *discordgo.User, period string, counts []storage.ModActionCount) *discordgo.MessageEmbed {
+	total := 0
+	lines := make([]string, 0, len(counts))
+	for _, c := range counts {
+		total += c.Count
+		lines = append(lines, fmt.Sprintf("**%s** — %d", c.Action, c.Count))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       lang.T("modstats_user_title", "user", mod.Username, "period", period),
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: mod.AvatarURL("128")},
+		Footer:      &discordgo.MessageEmbedFooter{Text: lang.T("modstats_total", "count", strconv.Itoa(total))},
+	}
+}
+
+// modStatsGuildEmbed ranks moderators by case count and totals each action.
+func modStatsGuildEmbed(period string, counts []storage.ModActionCount) *discordgo.MessageEmbed {
+	perMod := make(map[string]int)
+	perModActions := make(map[string][]string)
+	perAction := make(map[string]int)
+	total := 0
+	for _, c := range counts {
+		perMod[c.ModID] += c.Count
+		perModActions[c.ModID] = append(perModActions[c.ModID], fmt.Sprintf("%s %d", c.Action, c.Count))
+		perAction[c.Action] += c.Count
+		total += c.Count
+	}
+
+	mods := make([]string, 0, len(perMod))
+	for id := range perMod {
+		mods = append(mods, id)
+	}
+	sort.Slice(mods, func(a, b int) bool { return perMod[mods[a]] > perMod[mods[b]] })
+
+	var modLines []string
+	for n, id := range mods {
+		if n == maxModStatsRows {
+			modLines = append(modLines, lang.T("modstats_more", "count", strconv.Itoa(len(mods)-n)))
+			break
+		}
+		modLines = append(modLines, lang.T("modstats_mod_line",
+			"rank", strconv.Itoa(n+1), "mod_id", id, "count", strconv.Itoa(perMod[id]), "actions", strings.Join(perModActions[id], ", ")))
+	}
+
+	actions := make([]string, 0, len(perAction))
+	for a := range perAction {
+		actions = append(actions, a)
+	}
+	sort.Slice(actions, func(a, b int) bool { return perAction[actions[a]] > perAction[actions[b]] })
+	actionLines := make([]string, 0, len(actions))
+	for _, a := range actions {
+		actionLines = append(actionLines, fmt.Sprintf("**%s** — %d", a, perAction[a]))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       lang.T("modstats_guild_title", "period", period),
+		Description: strings.Join(modLines, "\n"),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: lang.T("modstats_actions_field"), Value: strings.Join(actionLines, "\n")},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: lang.T("modstats_total", "count", strconv.Itoa(total))},
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxNicknameLength is Discord's limit on nicknames.
+const maxNicknameLength = 32
+
+func nicknameCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "dehoist",
+			Description:              "Clean up member display names",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "all",
+					Description: "Sanitize the display name of every member now",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+// RegisterNicknames cleans display names as members join or change them.
+func RegisterNicknames(s *discordgo.Session, cfg *config.Config) {
+	nc := &cfg.Moderation.Nickname
+	if !nc.Enabled {
+		return
+	}
+
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		sanitizeMemberName(s, m.GuildID, m.Member, nc)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		sanitizeMemberName(s, m.GuildID, m.Member, nc)
+	})
+
+	log.Printf("[Nicknames] Active — dehoist: %v, normalize: %v", nc.Dehoist, nc.Normalize)
+}
+
+// dehoistTimeBudget is how long /dehoist all may spend renaming before it
+// stops and reports, leaving margin within the 15-minute interaction token.
+const dehoistTimeBudget = 12 * time.Minute
+
+func handleDehoistCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	// /dehoist always dehoists, even when automatic clean-up is off; name
+	// normalisation still follows the config.
+	nc := storage.Cfg.Moderation.Nickname
+	nc.Dehoist = true
+
+	deferEphemeral(s, i)
+	deadline := time.Now().Add(dehoistTimeBudget)
+
+	scanned, renamed, failed, skipped := 0, 0, 0, 0
+	after := ""
+	for {
+		members, err := s.GuildMembers(i.GuildID, after, 1000)
+		if err != nil {
+			followup(s, i, lang.T("dehoist_fetch_failed", "error", err.Error()))
+			return
+		}
+		for _, m := range members {
+			// Renames go one request at a time; past the deadline just count
+			// who was left so the moderator can run it again.
+			if time.Now().After(deadline) {
+				skipped++
+				continue
+			}
+			scanned++
+			switch sanitizeMemberName(s, i.GuildID, m, &nc) {
+			case nameRenamed:
+				renamed++
+			case nameFailed:
+				failed++
+			}
+		}
+		if len(members) < 1000 {
+			break
+		}
+		after = members[len(members)-1].User.ID
+	}
+
+	skippedNote := ""
+	if skipped > 0 {
+		skippedNote = "\n" + lang.T("dehoist_skipped", "count", strconv.Itoa(skipped))
+	}
+
+	logModEvent(s, i.GuildID, lang.T("dehoist_log_title"),
+		lang.T("dehoist_log", "mod_id", i.Member.User.ID, "renamed", strconv.Itoa(renamed), "scanned", strconv.Itoa(scanned), "failed", strconv.Itoa(failed))+skippedNote,
+		0x5865F2)
+	followup(s, i, lang.T("dehoist_done", "renamed", strconv.Itoa(renamed), "scanned", strconv.Itoa(scanned), "failed", strconv.Itoa(failed))+skippedNote)
+}
+
+type nameResult int
+
+const (
+	nameUnchanged nameResult = iota
+	nameRenamed
+	nameFailed
+)
+
+// sanitizeMemberName sets a clean nickname when the member's display name
+// needs one. Bots and Minecraft-linked members, whose nickname is their
+// in-game name, are left alone.
+func sanitizeMemberName(s *discordgo.Session, guildID string, m *discordgo.Member, nc *config.NicknameConfig) nameResult {
+	if m == nil || m.User == nil || m.User.Bot {
+		return nameUnchanged
+	}
+	if MCStore != nil {
+		if link, err := MCStore.LoadLink(m.User.ID); err == nil && link != nil {
+			return nameUnchanged
+		}
+	}
+
+	current := m.Nick
+	if current == "" {
+		current = m.User.GlobalName
+	}
+	if current == "" {
+		current = m.User.Username
+	}
+	clean := sanitizeName(current, nc)
+	if clean == current {
+		return nameUnchanged
+	}
+
+	if err := s.GuildMemberNickname(guildID, m.User.ID, clean); err != nil {
+		log.Printf("[Nicknames] Could not rename %s (%q → %q): %v", m.User.ID, current, clean, err)
+		return nameFailed
+	}
+	return nameRenamed
+}
+
+// sanitizeName applies the configured clean-ups to a display name.
+func sanitizeName(name string, nc *config.NicknameConfig) string {
+	if nc.Normalize {
+		name = normalizeName(name)
+	}
+	if nc.Dehoist {
+		name = strings.TrimLeftFunc(name, func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune(nc.HoistCharacters, r)
+		})
+	}
+	name = strings.TrimSpace(name)
+
+	if !mentionableName(name) {
+		return nc.Placeholder
+	}
+	if r := []rune(name); len(r) > maxNicknameLength {
+		name = strings.TrimSpace(string(r[:maxNicknameLength]))
+	}
+	return name
+}
+
+// maxCombiningMarks is how many combining marks may follow one character;
+// longer stacks are "zalgo" text rather than accents.
+const maxCombiningMarks = 2
+
+// normalizeName folds styled letters (fullwidth, mathematical, circled and
+// other compatibility forms) to their plain equivalents with NFKC, and drops
+// symbols, invisible characters and stacked combining marks. Letters from
+// other scripts and ordinary accents are kept.
+func normalizeName(name string) string {
+	var sb strings.Builder
+	sb.Grow(len(name))
+	marks := 0
+	for _, r := range norm.NFKC.String(name) {
+		if unicode.Is(unicode.Mn, r) {
+			marks++
+			if marks > maxCombiningMarks {
+				continue
+			}
+			sb.WriteRune(r)
+			continue
+		}
+		marks = 0
+		if unicode.In(r, unicode.Me, unicode.Cf, unicode.Co, unicode.So) {
+			continue
+		}
+		if unicode.IsSpace(r) {
+			r = ' '
+		}
+		sb.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// mentionableName reports whether a name has at least one letter or digit in
+// any script, rather than being empty or made only of symbols and invisible
+// characters.
+func mentionableName(name string) bool {
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,14 +2,112 @@ package handlers
 
 import (
 	"log"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"discord-bot/config"
+	"discord-bot/lang"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// mentionSet is who a message pings, ignoring the author and bots.
+type mentionSet struct {
+	users    []string
+	roles    []string
+	everyone bool
+}
+
+func (ms mentionSet) empty() bool {
+	return len(ms.users) == 0 && len(ms.roles) == 0 && !ms.everyone
+}
+
+func (ms mentionSet) count() int {
+	n := len(ms.users) + len(ms.roles)
+	if ms.everyone {
+		n++
+	}
+	return n
+}
+
+// without returns the mentions in ms that are missing from other.
+func (ms mentionSet) without(other mentionSet) mentionSet {
+	keep := func(ids, drop []string) []string {
+		gone := make(map[string]bool, len(drop))
+		for _, id := range drop {
+			gone[id] = true
+		}
+		var out []string
+		for _, id := range ids {
+			if !gone[id] {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+	return mentionSet{
+		users:    keep(ms.users, other.users),
+		roles:    keep(ms.roles, other.roles),
+		everyone: ms.everyone && !other.everyone,
+	}
+}
+
+func messageMentions(m *discordgo.Message) mentionSet {
+	var ms mentionSet
+	for _, u := range m.Mentions {
+		if u.Bot || (m.Author != nil && u.ID == m.Author.ID) {
+			continue
+		}
+		ms.users = append(ms.users, u.ID)
+	}
+	ms.roles = append(ms.roles, m.MentionRoles...)
+	ms.everyone = m.MentionEveryone
+	return ms
+}
+
+type ghostPingEntry struct {
+	guildID   string
+	channelID string
+	authorID  string
+	mentions  mentionSet
+	sentAt    time.Time
+}
+
+var (
+	// ghostPingCache remembers recent messages that pinged someone, keyed by message ID.
+	ghostPingCache   = make(map[string]ghostPingEntry)
+	ghostPingCacheMu sync.Mutex
+)
+
 func RegisterNoPing(s *discordgo.Session, cfg *config.Config) {
+	if cfg.NoPing.GhostPing {
+		window := time.Duration(cfg.NoPing.GhostPingWindowMinutes) * time.Minute
+		s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+			cacheGhostPing(m.Message, window)
+		})
+		s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageDelete) {
+			handleGhostPingDelete(s, m, window)
+		})
+		s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+			handleGhostPingEdit(s, m, window)
+		})
+		log.Printf("[NoPing] Ghost-ping detection active (%s window)", window)
+	}
+
+	exemptRoles := make(map[string]bool, len(cfg.NoPing.ExemptRoles))
+	for _, id := range cfg.NoPing.ExemptRoles {
+		exemptRoles[strings.TrimSpace(id)] = true
+	}
+
+	if cfg.NoPing.MaxMentions > 0 {
+		s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+			handleMassMention(s, m, &cfg.NoPing, exemptRoles)
+		})
+		log.Printf("[NoPing] Mass-mention detection active (more than %d mentions)", cfg.NoPing.MaxMentions)
+	}
+
 	if !cfg.NoPing.Enabled || len(cfg.NoPing.ProtectedRoles) == 0 {
 		return
 	}
@@ -21,19 +119,23 @@ func RegisterNoPing(s *discordgo.Session, cfg *config.Config) {
 	}
 
 	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		handleNoPing(s, m, &cfg.NoPing, protected)
+		handleNoPing(s, m, &cfg.NoPing, protected, exemptRoles)
 	})
 
 	log.Printf("[NoPing] Active — protecting %d role(s)", len(protected))
 }
 
-func handleNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, protected map[string]bool) {
-	if m.Author == nil || m.Author.Bot {
+func handleNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, protected, exemptRoles map[string]bool) {
+	if noPingExempt(m, cfg, exemptRoles) {
 		return
 	}
+	ms := messageMentions(m.Message)
+	if cfg.MaxMentions > 0 && ms.count() > cfg.MaxMentions {
+		return // handleMassMention deals with it as one offence
+	}
 
 	// Check direct role pings (@Owner, @Admin, etc.)
-	for _, roleID := range m.MentionRoles {
+	for _, roleID := range ms.roles {
 		if !protected[roleID] {
 			continue
 		}
@@ -41,17 +143,18 @@ func handleNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.
 		if r, err := s.State.Role(m.GuildID, roleID); err == nil {
 			roleName = r.Name
 		}
-		triggerNoPing(s, m, cfg, roleName)
+		triggerNoPing(s, m, cfg, roleName, false)
 		return
 	}
 
 	// Check user pings — if the pinged user holds a protected role, block it too.
 	// e.g. @saladedecparisun where saladedecparisun has the Owner role.
-	for _, mentioned := range m.Mentions {
-		if mentioned.ID == m.Author.ID {
-			continue // ignore self-mentions
+	for _, userID := range ms.users {
+		reply := isReplyPing(m.Message, userID)
+		if reply && cfg.AllowReplyPings {
+			continue
 		}
-		member, err := s.GuildMember(m.GuildID, mentioned.ID)
+		member, err := s.GuildMember(m.GuildID, userID)
 		if err != nil {
 			continue
 		}
@@ -63,18 +166,118 @@ func handleNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.
 			if r, err := s.State.Role(m.GuildID, roleID); err == nil {
 				roleName = r.Name
 			}
-			triggerNoPing(s, m, cfg, roleName)
+			triggerNoPing(s, m, cfg, roleName, reply)
 			return
 		}
 	}
 }
 
-func triggerNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, roleName string) {
+// noPingExempt reports whether the NoPing rules skip m: bot messages, exempt
+// channels and members with an exempt role.
+func noPingExempt(m *discordgo.MessageCreate, cfg *config.NoPingConfig, exemptRoles map[string]bool) bool {
+	if m.Author == nil || m.Author.Bot {
+		return true
+	}
+	for _, id := range cfg.ExemptChannels {
+		if id == m.ChannelID {
+			return true
+		}
+	}
+	if m.Member != nil {
+		for _, roleID := range m.Member.Roles {
+			if exemptRoles[roleID] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleMassMention removes messages that mention more than cfg.MaxMentions
+// users and roles and counts them as a NoPing offence.
+func handleMassMention(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, exemptRoles map[string]bool) {
+	if m.GuildID == "" || noPingExempt(m, cfg, exemptRoles) {
+		return
+	}
+	ms := messageMentions(m.Message)
+	if ms.count() <= cfg.MaxMentions {
+		return
+	}
+	offence := lang.T("noping_mass_mention_reason", "count", strconv.Itoa(ms.count()))
+	action := "log"
+	if cfg.DeleteMessage {
+		action = "delete"
+	}
+	applyAutoModAction(s, m.GuildID, m.ChannelID, m.ID, m.Author, action, "", offence)
+	escalateNoPing(s, m, cfg, offence)
+}
+
+// isReplyPing reports whether userID is only mentioned because the message
+// replies to them with the reply ping left on.
+func isReplyPing(m *discordgo.Message, userID string) bool {
+	if m.ReferencedMessage == nil || m.ReferencedMessage.Author == nil || m.ReferencedMessage.Author.ID != userID {
+		return false
+	}
+	return !strings.Contains(m.Content, "<@"+userID+">") && !strings.Contains(m.Content, "<@!"+userID+">")
+}
+
+func triggerNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, roleName string, reply bool) {
 	if cfg.DeleteMessage {
+		forgetGhostPing(m.ID)
 		_ = s.ChannelMessageDelete(m.ChannelID, m.ID)
 	}
-	msg := buildNoPingMessage(cfg.Message, m.Author.ID, roleName)
+	template := cfg.Message
+	if reply && cfg.ReplyMessage != "" {
+		template = cfg.ReplyMessage
+	}
+	msg := buildNoPingMessage(template, m.Author.ID, roleName)
 	sendTemp(s, m.ChannelID, msg, 8)
+
+	escalateNoPing(s, m, cfg, lang.T("noping_offence_role", "role", roleName))
+}
+
+var (
+	// noPingOffences holds recent offence times per guild:user.
+	noPingOffences   = make(map[string][]time.Time)
+	noPingOffencesMu sync.Mutex
+)
+
+// escalateNoPing records an offence and, once the member reaches a step of the
+// escalation ladder, warns or times them out through the moderation pipeline.
+// offence describes what the member did, for the case reason.
+func escalateNoPing(s *discordgo.Session, m *discordgo.MessageCreate, cfg *config.NoPingConfig, offence string) {
+	if len(cfg.Escalation) == 0 {
+		return
+	}
+	window := time.Duration(cfg.EscalationWindowMinutes) * time.Minute
+	key := m.GuildID + ":" + m.Author.ID
+	now := time.Now()
+
+	noPingOffencesMu.Lock()
+	recent := noPingOffences[key][:0]
+	for _, t := range noPingOffences[key] {
+		if now.Sub(t) <= window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	noPingOffences[key] = recent
+	count := len(recent)
+	noPingOffencesMu.Unlock()
+
+	var step *config.NoPingEscalationStep
+	for idx := range cfg.Escalation {
+		st := &cfg.Escalation[idx]
+		if st.Offences <= count && (step == nil || st.Offences > step.Offences) {
+			step = st
+		}
+	}
+	if step == nil || (step.Action != "warn" && step.Action != "timeout") {
+		return
+	}
+
+	reason := lang.T("noping_escalation_reason", "offence", offence, "count", strconv.Itoa(count), "minutes", strconv.Itoa(cfg.EscalationWindowMinutes))
+	applyAutoModAction(s, m.GuildID, m.ChannelID, "", m.Author, step.Action, step.Duration, reason)
 }
 
 // buildNoPingMessage replaces {user} and {role} placeholders in the configured message.
@@ -87,3 +290,100 @@ func buildNoPingMessage(template, userID, roleName string) string {
 	msg = strings.ReplaceAll(msg, "{role}", roleName)
 	return msg
 }
+
+func cacheGhostPing(m *discordgo.Message, window time.Duration) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+		return
+	}
+	ms := messageMentions(m)
+	if ms.empty() {
+		return
+	}
+
+	now := time.Now()
+	ghostPingCacheMu.Lock()
+	for id, e := range ghostPingCache {
+		if now.Sub(e.sentAt) > window {
+			delete(ghostPingCache, id)
+		}
+	}
+	ghostPingCache[m.ID] = ghostPingEntry{
+		guildID:   m.GuildID,
+		channelID: m.ChannelID,
+		authorID:  m.Author.ID,
+		mentions:  ms,
+		sentAt:    now,
+	}
+	ghostPingCacheMu.Unlock()
+}
+
+// forgetGhostPing drops a message from the cache before the bot deletes it
+// itself, so moderation deletes aren't reported as ghost pings.
+func forgetGhostPing(messageID string) {
+	ghostPingCacheMu.Lock()
+	delete(ghostPingCache, messageID)
+	ghostPingCacheMu.Unlock()
+}
+
+func handleGhostPingDelete(s *discordgo.Session, m *discordgo.MessageDelete, window time.Duration) {
+	ghostPingCacheMu.Lock()
+	e, ok := ghostPingCache[m.ID]
+	delete(ghostPingCache, m.ID)
+	ghostPingCacheMu.Unlock()
+	if !ok || time.Since(e.sentAt) > window {
+		return
+	}
+	reportGhostPing(s, e, e.mentions, "noping_ghost_deleted")
+}
+
+func handleGhostPingEdit(s *discordgo.Session, m *discordgo.MessageUpdate, window time.Duration) {
+	// Updates without an author are embed unfurls, not edits.
+	if m.Author == nil {
+		return
+	}
+	ghostPingCacheMu.Lock()
+	e, ok := ghostPingCache[m.ID]
+	if ok {
+		now := messageMentions(m.Message)
+		removed := e.mentions.without(now)
+		e.mentions = now
+		ghostPingCache[m.ID] = e
+		ghostPingCacheMu.Unlock()
+		if !removed.empty() && time.Since(e.sentAt) <= window {
+			reportGhostPing(s, e, removed, "noping_ghost_edited")
+		}
+		return
+	}
+	ghostPingCacheMu.Unlock()
+}
+
+// reportGhostPing posts who was pinged and by whom. Only the pinged users are
+// notified, through mentions in the message content since embeds never ping;
+// role and @everyone mentions are shown without pinging again.
+func reportGhostPing(s *discordgo.Session, e ghostPingEntry, ms mentionSet, key string) {
+	var targets, pings []string
+	for _, id := range ms.users {
+		targets = append(targets, "<@"+id+">")
+		pings = append(pings, "<@"+id+">")
+	}
+	for _, id := range ms.roles {
+		targets = append(targets, "<@&"+id+">")
+	}
+	if ms.everyone {
+		targets = append(targets, "@everyone")
+	}
+
+	_, err := s.ChannelMessageSendComplex(e.channelID, &discordgo.MessageSend{
+		Content: strings.Join(pings, " "),
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       lang.T("noping_ghost_title"),
+			Description: lang.T(key, "author_id", e.authorID, "targets", strings.Join(targets, ", ")),
+			Color:       0x99AAB5,
+			Timestamp:   e.sentAt.Format(time.RFC3339),
+		}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: ms.users},
+	})
+	if err != nil {
+		log.Printf("[NoPing] Failed to report ghost ping in %s: %v", e.channelID, err)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxNoteLength keeps a note within a single embed field.
+const maxNoteLength = 1000
+
+func noteCommands() []*discordgo.ApplicationCommand {
+	userOpt := &discordgo.ApplicationCommandOption{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "User the note is about", Required: true}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "note",
+			Description:              "Private staff notes about users",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Description: "Add a note about a user",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						userOpt,
+						{Type: discordgo.ApplicationCommandOptionString, Name: "content", Description: "The note", Required: true, MaxLength: maxNoteLength},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "Show the notes about a user",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     []*discordgo.ApplicationCommandOption{userOpt},
+				},
+				{
+					Name:        "remove",
+					Description: "Delete a note",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						userOpt,
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "id", Description: "Note ID (see /note list)", Required: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func handleNoteCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	if storage.DB == nil {
+		respond(s, i, lang.T("note_no_database"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	om := subOptMap(sub.Options)
+	target := om["user"].UserValue(s)
+
+	switch sub.Name {
+	case "add":
+		id, err := storage.DB.AddNote(i.GuildID, storage.ModNote{
+			GuildID:   i.GuildID,
+			UserID:    target.ID,
+			ModID:     i.Member.User.ID,
+			Content:   om["content"].StringValue(),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+		if err != nil {
+			respond(s, i, lang.T("note_failed", "error", err.Error()), true)
+			return
+		}
+		respond(s, i, lang.T("note_added", "id", strconv.Itoa(id), "user", target.Username), true)
+
+	case "list":
+		notes, err := storage.DB.GetNotes(i.GuildID, target.ID)
+		if err != nil {
+			respond(s, i, lang.T("note_failed", "error", err.Error()), true)
+			return
+		}
+		if len(notes) == 0 {
+			respond(s, i, lang.T("note_none", "user", target.Username), true)
+			return
+		}
+		respondEmbed(s, i, notesEmbed(target, notes), true)
+
+	case "remove":
+		id := int(om["id"].IntValue())
+		ok, err := storage.DB.RemoveNote(i.GuildID, target.ID, id)
+		if err != nil {
+			respond(s, i, lang.T("note_failed", "error", err.Error()), true)
+			return
+		}
+		if !ok {
+			respond(s, i, lang.T("note_not_found", "id", strconv.Itoa(id), "user", target.Username), true)
+			return
+		}
+		respond(s, i, lang.T("note_removed", "id", strconv.Itoa(id), "user", target.Username), true)
+	}
+}
+
+// notesEmbedBudget is how many characters of fields notesEmbed uses, leaving
+// room for the title and footer within Discord's 6000-character embed limit.
+const notesEmbedBudget = 5500
+
+// notesEmbed lists a user's notes, newest last. Discord allows 25 fields and
+// 6000 characters per embed, so only the most recent notes that fit are shown.
+func notesEmbed(target *discordgo.User, notes []storage.ModNote) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	used := 0
+	for idx := len(notes) - 1; idx >= 0 && len(fields) < 25; idx-- {
+		n := notes[idx]
+		when := n.Timestamp
+		if t, err := time.Parse(time.RFC3339, n.Timestamp); err == nil {
+			when = fmt.Sprintf("<t:%d:d>", t.Unix())
+		}
+		field := &discordgo.MessageEmbedField{
+			Name:  lang.T("note_entry_title", "id", strconv.Itoa(n.ID)),
+			Value: lang.T("note_entry", "content", n.Content, "mod_id", n.ModID, "date", when),
+		}
+		size := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+		if used+size > notesEmbedBudget {
+			break
+		}
+		used += size
+		fields = append(fields, field)
+	}
+	slices.Reverse(fields)
+
+	embed := &discordgo.MessageEmbed{
+		Title: lang.T("note_list_title", "user", target.Username, "count", strconv.Itoa(len(notes))),
+		Color: 0x5865F2,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: target.AvatarURL("128"),
+		},
+		Fields: fields,
+	}
+	if len(notes) > len(fields) {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: lang.T("note_list_truncated", "count", strconv.Itoa(len(fields)))}
+	}
+	return embed
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/lang"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxPurgeScan caps how many messages a single /purge may look through.
+const maxPurgeScan = 5000
+
+// bulkDeleteMaxAge is how old a message may be before Discord refuses to bulk
+// delete it. A small margin avoids racing the cutoff.
+const bulkDeleteMaxAge = 14*24*time.Hour - time.Minute
+
+// maxPurgeOld caps how many messages older than 14 days one /purge deletes.
+// They go one request at a time, so this keeps a run short.
+const maxPurgeOld = 200
+
+// purgeTimeBudget is how long a /purge may spend deleting before it stops and
+// reports, leaving margin within the 15-minute interaction token.
+const purgeTimeBudget = 12 * time.Minute
+
+var linkPattern = regexp.MustCompile(`(?i)https?://\S+`)
+
+// purgeFilter holds the criteria a message must match to be purged.
+type purgeFilter struct {
+	userID        string
+	bots          bool
+	attachments   bool
+	links         bool
+	embeds        bool
+	contains      string
+	pattern       *regexp.Regexp
+	includePinned bool
+}
+
+func (f *purgeFilter) match(m *discordgo.Message) bool {
+	if m.Pinned && !f.includePinned {
+		return false
+	}
+	if f.userID != "" && (m.Author == nil || m.Author.ID != f.userID) {
+		return false
+	}
+	if f.bots && (m.Author == nil || !m.Author.Bot) {
+		return false
+	}
+	if f.attachments && len(m.Attachments) == 0 {
+		return false
+	}
+	if f.links && !linkPattern.MatchString(m.Content) {
+		return false
+	}
+	if f.embeds && len(m.Embeds) == 0 {
+		return false
+	}
+	if f.contains != "" && !strings.Contains(strings.ToLower(m.Content), f.contains) {
+		return false
+	}
+	if f.pattern != nil && !f.pattern.MatchString(m.Content) {
+		return false
+	}
+	return true
+}
+
+// describe lists the active filters for the mod log summary.
+func (f *purgeFilter) describe() string {
+	var parts []string
+	if f.userID != "" {
+		parts = append(parts, "user <@"+f.userID+">")
+	}
+	if f.bots {
+		parts = append(parts, "bots")
+	}
+	if f.attachments {
+		parts = append(parts, "attachments")
+	}
+	if f.links {
+		parts = append(parts, "links")
+	}
+	if f.embeds {
+		parts = append(parts, "embeds")
+	}
+	if f.contains != "" {
+		parts = append(parts, fmt.Sprintf("contains `%s`", f.contains))
+	}
+	if f.pattern != nil {
+		parts = append(parts, fmt.Sprintf("regex `%s`", f.pattern.String()))
+	}
+	if f.includePinned {
+		parts = append(parts, "including pinned")
+	}
+	if len(parts) == 0 {
+		return lang.T("mod_purge_no_filters")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func handlePurge(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionMap(i)
+	count := int(opts["count"].IntValue())
+	if count < 1 || count > maxPurgeScan {
+		respond(s, i, lang.T("mod_purge_invalid_count", "max", strconv.Itoa(maxPurgeScan)), true)
+		return
+	}
+
+	filter := &purgeFilter{
+		contains:      strings.ToLower(optStr(opts, "contains", "")),
+		includePinned: opts["include_pinned"] != nil && opts["include_pinned"].BoolValue(),
+		bots:          opts["bots"] != nil && opts["bots"].BoolValue(),
+		attachments:   opts["attachments"] != nil && opts["attachments"].BoolValue(),
+		links:         opts["links"] != nil && opts["links"].BoolValue(),
+		embeds:        opts["embeds"] != nil && opts["embeds"].BoolValue(),
+	}
+	if u, ok := opts["user"]; ok {
+		filter.userID = u.UserValue(s).ID
+	}
+	if raw := optStr(opts, "regex", ""); raw != "" {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			respond(s, i, lang.T("mod_purge_invalid_regex", "error", err.Error()), true)
+			return
+		}
+		filter.pattern = re
+	}
+	before := strings.TrimSpace(optStr(opts, "before", ""))
+	after := strings.TrimSpace(optStr(opts, "after", ""))
+	for _, id := range []string{before, after} {
+		if id == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			respond(s, i, lang.T("mod_purge_invalid_id", "id", id), true)
+			return
+		}
+	}
+
+	started := time.Now()
+	deferEphemeral(s, i)
+
+	ids, scanned, err := collectPurgeMessages(s, i.ChannelID, count, before, after, filter)
+	if err != nil && len(ids) == 0 {
+		followup(s, i, lang.T("mod_purge_fetch_failed", "error", err.Error()))
+		return
+	}
+	if len(ids) == 0 {
+		followup(s, i, lang.T("mod_purge_no_messages"))
+		return
+	}
+
+	deleted, old, failed, skipped := deletePurgeMessages(s, i.ChannelID, ids, started.Add(purgeTimeBudget))
+	skippedNote := ""
+	if skipped > 0 {
+		skippedNote = "\n" + lang.T("mod_purge_skipped", "count", strconv.Itoa(skipped))
+	}
+
+	logModEvent(s, i.GuildID, lang.T("mod_purge_log_title"),
+		lang.T("mod_purge_log",
+			"mod_id", i.Member.User.ID,
+			"channel_id", i.ChannelID,
+			"deleted", strconv.Itoa(deleted),
+			"scanned", strconv.Itoa(scanned),
+			"old", strconv.Itoa(old),
+			"failed", strconv.Itoa(failed),
+			"filters", filter.describe(),
+		)+skippedNote,
+		0xFEE75C)
+
+	followup(s, i, lang.T("mod_purge_success_detail",
+		"count", strconv.Itoa(deleted),
+		"scanned", strconv.Itoa(scanned),
+		"old", strconv.Itoa(old),
+		"failed", strconv.Itoa(failed),
+	)+skippedNote)
+}
+
+// collectPurgeMessages pages backwards through the channel, starting at before
+// (or the latest message) and stopping at after, until limit messages have
+// been scanned. It returns the IDs that match the filter, newest first.
+func collectPurgeMessages(s *discordgo.Session, channelID string, limit int, before, after string, filter *purgeFilter) ([]string, int, error) {
+	var afterID uint64
+	if after != "" {
+		afterID, _ = strconv.ParseUint(after, 10, 64)
+	}
+
+	ids := make([]string, 0)
+	scanned := 0
+	cursor := before
+	for scanned < limit {
+		page := limit - scanned
+		if page > 100 {
+			page = 100
+		}
+		msgs, err := s.ChannelMessages(channelID, page, cursor, "", "")
+		if err != nil {
+			return ids, scanned, err
+		}
+		if len(msgs) == 0 {
+			break
+		}
+
+		reachedAfter := false
+		for _, m := range msgs {
+			if afterID != 0 {
+				if id, _ := strconv.ParseUint(m.ID, 10, 64); id <= afterID {
+					reachedAfter = true
+					break
+				}
+			}
+			scanned++
+			if filter.match(m) {
+				ids = append(ids, m.ID)
+			}
+		}
+		if reachedAfter || len(msgs) < page {
+			break
+		}
+		cursor = msgs[len(msgs)-1].ID
+	}
+	return ids, scanned, nil
+}
+
+// deletePurgeMessages bulk deletes recent messages in batches of 100 and falls
+// back to deleting older ones individually, which bulk delete rejects. At most
+// maxPurgeOld old messages are deleted, and none after deadline; the rest are
+// counted as skipped.
+func deletePurgeMessages(s *discordgo.Session, channelID string, ids []string, deadline time.Time) (deleted, old, failed, skipped int) {
+	recent := make([]string, 0, len(ids))
+	var aged []string
+	for _, id := range ids {
+		if time.Since(snowflakeTime(id)) < bulkDeleteMaxAge {
+			recent = append(recent, id)
+		} else {
+			aged = append(aged, id)
+		}
+	}
+
+	for start := 0; start < len(recent); start += 100 {
+		end := start + 100
+		if end > len(recent) {
+			end = len(recent)
+		}
+		batch := recent[start:end]
+
+		var err error
+		if len(batch) == 1 {
+			forgetGhostPing(batch[0])
+			err = s.ChannelMessageDelete(channelID, batch[0])
+		} else {
+			err = s.ChannelMessagesBulkDelete(channelID, batch)
+		}
+		if err != nil {
+			log.Printf("[Purge] Bulk delete of %d message(s) in %s failed: %v", len(batch), channelID, err)
+			failed += len(batch)
+			continue
+		}
+		deleted += len(batch)
+	}
+
+	for n, id := range aged {
+		if n >= maxPurgeOld || time.Now().After(deadline) {
+			skipped = len(aged) - n
+			break
+		}
+		forgetGhostPing(id)
+		if err := s.ChannelMessageDelete(channelID, id); err != nil {
+			failed++
+			continue
+		}
+		deleted++
+		old++
+	}
+	return deleted, old, failed, skipped
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// customEmojiRe matches a pasted custom emoji such as <:oreo:123> or <a:spin:456>.
+var customEmojiRe = regexp.MustCompile(`^<a?:([A-Za-z0-9_]+):(\d+)>$`)
+
+func reactionRoleCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "reactionrole",
+			Description:              "Give roles when members react to an existing message",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Description: "Map an emoji on a message to a role",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Channel the message is in", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "message_id", Description: "ID of the message members react to", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "emoji", Description: "Emoji to react with (unicode or custom)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role given for this reaction", Required: true},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Remove an emoji mapping from a message",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "message_id", Description: "ID of the message", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "emoji", Description: "Emoji to unbind", Required: true},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "List all reaction-role messages",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+// RegisterReactionRoles wires the reaction add/remove handlers for reaction-role menus.
+func RegisterReactionRoles(s *discordgo.Session) {
+	s.AddHandler(func(s *discordgo.Session, e *discordgo.MessageReactionAdd) {
+		handleReactionRoleAdd(s, e)
+	})
+	s.AddHandler(func(s *discordgo.Session, e *discordgo.MessageReactionRemove) {
+		handleReactionRoleRemove(s, e)
+	})
+}
+
+func handleReactionRoleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "add":
+		handleReactionRoleBind(s, i, sub.Options)
+	case "remove":
+		handleReactionRoleUnbind(s, i, sub.Options)
+	case "list":
+		handleReactionRoleList(s, i)
+	}
+}
+
+func handleReactionRoleBind(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	ch := om["channel"].ChannelValue(s)
+	messageID := strings.TrimSpace(om["message_id"].StringValue())
+	emojiInput := strings.TrimSpace(om["emoji"].StringValue())
+	role := om["role"].RoleValue(s, i.GuildID)
+
+	emoji := normalizeReactionEmoji(emojiInput)
+	if emoji == "" {
+		respond(s, i, lang.T("reactionrole_invalid_emoji"), true)
+		return
+	}
+	if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
+		respond(s, i, lang.T("reactionrole_hierarchy", "warning", w), true)
+		return
+	}
+	if _, err := s.ChannelMessage(ch.ID, messageID); err != nil {
+		respond(s, i, lang.T("reactionrole_message_not_found", "error", err.Error()), true)
+		return
+	}
+	if err := s.MessageReactionAdd(ch.ID, messageID, emoji); err != nil {
+		respond(s, i, lang.T("reactionrole_react_failed", "error", err.Error()), true)
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := reactionMenuIndex(gs, messageID)
+	if idx < 0 {
+		gs.RoleMenus = append(gs.RoleMenus, config.RoleMenu{
+			ID:        nextRoleMenuID(gs),
+			Title:     "Reaction roles",
+			ChannelID: ch.ID,
+			MessageID: messageID,
+			Reactions: true,
+			Roles:     []config.RoleMenuEntry{},
+		})
+		idx = len(gs.RoleMenus) - 1
+	}
+	menu := &gs.RoleMenus[idx]
+	replaced := false
+	for n := range menu.Roles {
+		if menu.Roles[n].Emoji == emoji {
+			menu.Roles[n].RoleID = role.ID
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		menu.Roles = append(menu.Roles, config.RoleMenuEntry{
+			RoleID: role.ID,
+			Label:  role.Name,
+			Emoji:  emoji,
+		})
+	}
+	menuID := menu.ID
+	gs.Unlock()
+	_ = gs.Save()
+
+	respond(s, i, lang.T("reactionrole_bound",
+		"emoji", emojiInput,
+		"role_id", role.ID,
+		"channel_id", ch.ID,
+		"id", menuID,
+	), true)
+}
+
+func handleReactionRoleUnbind(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	messageID := strings.TrimSpace(om["message_id"].StringValue())
+	emojiInput := strings.TrimSpace(om["emoji"].StringValue())
+	emoji := normalizeReactionEmoji(emojiInput)
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := reactionMenuIndex(gs, messageID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("reactionrole_not_bound"), true)
+		return
+	}
+	menu := &gs.RoleMenus[idx]
+	channelID := menu.ChannelID
+	found := false
+	kept := make([]config.RoleMenuEntry, 0, len(menu.Roles))
+	for _, r := range menu.Roles {
+		if r.Emoji == emoji {
+			found = true
+			continue
+		}
+		kept = append(kept, r)
+	}
+	menu.Roles = kept
+	if len(kept) == 0 {
+		gs.RoleMenus = append(gs.RoleMenus[:idx:idx], gs.RoleMenus[idx+1:]...)
+	}
+	gs.Unlock()
+
+	if !found {
+		respond(s, i, lang.T("reactionrole_not_bound"), true)
+		return
+	}
+	_ = gs.Save()
+	_ = s.MessageReactionRemove(channelID, messageID, emoji, "@me")
+
+	respond(s, i, lang.T("reactionrole_unbound", "emoji", emojiInput), true)
+}
+
+func handleReactionRoleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	var sb strings.Builder
+	count := 0
+	for _, m := range gs.RoleMenus {
+		if !m.Reactions {
+			continue
+		}
+		count++
+		sb.WriteString(fmt.Sprintf("`%s` — https://discord.com/channels/%s/%s/%s\n", m.ID, i.GuildID, m.ChannelID, m.MessageID))
+		for _, r := range m.Roles {
+			sb.WriteString(fmt.Sprintf("　%s → <@&%s>\n", displayReactionEmoji(r.Emoji), r.RoleID))
+		}
+	}
+	gs.Unlock()
+
+	if count == 0 {
+		respond(s, i, lang.T("reactionrole_none"), true)
+		return
+	}
+	respond(s, i, lang.T("reactionrole_list_header")+sb.String(), true)
+}
+
+func handleReactionRoleAdd(s *discordgo.Session, e *discordgo.MessageReactionAdd) {
+	if e.GuildID == "" || (s.State.User != nil && e.UserID == s.State.User.ID) {
+		return
+	}
+	if e.Member != nil && e.Member.User != nil && e.Member.User.Bot {
+		return
+	}
+
+	menuID, entry, groupRoles, ok := lookupReactionRole(e.GuildID, e.MessageID, e.Emoji.APIName())
+	if !ok {
+		return
+	}
+
+	member := e.Member
+	if member == nil {
+		m, err := s.GuildMember(e.GuildID, e.UserID)
+		if err != nil {
+			return
+		}
+		member = m
+	}
+
+	if reason := roleMenuRefusal(member, entry, groupRoles); reason != "" {
+		_ = s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+		if ch, err := s.UserChannelCreate(e.UserID); err == nil {
+			_, _ = s.ChannelMessageSend(ch.ID, reason)
+		}
+		return
+	}
+
+	if err := s.GuildMemberRoleAdd(e.GuildID, e.UserID, entry.RoleID); err != nil {
+		log.Printf("[ReactionRole] Failed to give role %s to %s: %v", entry.RoleID, e.UserID, err)
+		return
+	}
+	if member.User != nil {
+		startMenuRoleExpiry(s, e.GuildID, member.User, entry, menuID)
+	}
+}
+
+func handleReactionRoleRemove(s *discordgo.Session, e *discordgo.MessageReactionRemove) {
+	if e.GuildID == "" || (s.State.User != nil && e.UserID == s.State.User.ID) {
+		return
+	}
+
+	_, entry, _, ok := lookupReactionRole(e.GuildID, e.MessageID, e.Emoji.APIName())
+	if !ok {
+		return
+	}
+	if err := s.GuildMemberRoleRemove(e.GuildID, e.UserID, entry.RoleID); err != nil {
+		log.Printf("[ReactionRole] Failed to remove role %s from %s: %v", entry.RoleID, e.UserID, err)
+	}
+	clearMenuRoleExpiry(e.GuildID, e.UserID, entry.RoleID)
+}
+
+// lookupReactionRole finds the menu and entry bound to an emoji on a message,
+// along with the other roles in the entry's exclusion group.
+func lookupReactionRole(guildID, messageID, emoji string) (string, config.RoleMenuEntry, map[string]bool, bool) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	defer gs.Unlock()
+
+	idx := reactionMenuIndex(gs, messageID)
+	if idx < 0 {
+		return "", config.RoleMenuEntry{}, nil, false
+	}
+	var entry config.RoleMenuEntry
+	found := false
+	for _, r := range gs.RoleMenus[idx].Roles {
+		if r.Emoji == emoji {
+			entry = r
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", config.RoleMenuEntry{}, nil, false
+	}
+
+	groupRoles := make(map[string]bool)
+	if entry.ExclusionGroup != "" {
+		for _, m := range gs.RoleMenus {
+			for _, r := range m.Roles {
+				if r.ExclusionGroup == entry.ExclusionGroup && r.RoleID != entry.RoleID {
+					groupRoles[r.RoleID] = true
+				}
+			}
+		}
+	}
+	return gs.RoleMenus[idx].ID, entry, groupRoles, true
+}
+
+// SyncReactionRoles re-adds the bot's reactions to every reaction-role message
+// and grants roles for reactions that were added while the bot was offline.
+// Roles are never taken away here: a member without a reaction may hold the
+// role through a manual grant, /temprole, a join or sticky role.
+func SyncReactionRoles(s *discordgo.Session, gs *config.GuildState) {
+	gs.Lock()
+	guildID := gs.GuildID
+	var menus []config.RoleMenu
+	for _, m := range gs.RoleMenus {
+		if m.Reactions {
+			m.Roles = append([]config.RoleMenuEntry(nil), m.Roles...)
+			menus = append(menus, m)
+		}
+	}
+	gs.Unlock()
+	if len(menus) == 0 {
+		return
+	}
+
+	botID := ""
+	if s.State.User != nil {
+		botID = s.State.User.ID
+	}
+
+	for _, m := range menus {
+		for _, entry := range m.Roles {
+			if err := s.MessageReactionAdd(m.ChannelID, m.MessageID, entry.Emoji); err != nil {
+				var restErr *discordgo.RESTError
+				if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage {
+					log.Printf("[ReactionRole] Message %s for menu %s no longer exists — skipping", m.MessageID, m.ID)
+					break
+				}
+				log.Printf("[ReactionRole] Could not re-add %s on %s: %v", entry.Emoji, m.MessageID, err)
+				continue
+			}
+
+			_, _, groupRoles, _ := lookupReactionRole(guildID, m.MessageID, entry.Emoji)
+			after := ""
+			for {
+				users, err := s.MessageReactions(m.ChannelID, m.MessageID, entry.Emoji, 100, "", after, discordgo.ReactionTypeNormal)
+				if err != nil {
+					break
+				}
+				for _, u := range users {
+					if u.Bot || u.ID == botID {
+						continue
+					}
+					member, err := cachedMember(s, guildID, u.ID)
+					if err != nil || memberHasRole(member, entry.RoleID) {
+						continue
+					}
+					if roleMenuRefusal(member, entry, groupRoles) != "" {
+						continue
+					}
+					if err := s.GuildMemberRoleAdd(guildID, u.ID, entry.RoleID); err != nil {
+						continue
+					}
+					if member.User != nil {
+						startMenuRoleExpiry(s, guildID, member.User, entry, m.ID)
+					}
+				}
+				if len(users) < 100 {
+					break
+				}
+				after = users[len(users)-1].ID
+			}
+		}
+	}
+	log.Printf("[ReactionRole] Resynced %d reaction-role message(s)", len(menus))
+}
+
+// removeMenuReaction takes a member's reaction off a reaction-role menu for the
+// entry giving roleID. It does nothing for dropdown menus.
+func removeMenuReaction(s *discordgo.Session, guildID, menuID, userID, roleID string) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	var channelID, messageID, emoji string
+	for _, m := range gs.RoleMenus {
+		if m.ID != menuID || !m.Reactions {
+			continue
+		}
+		for _, r := range m.Roles {
+			if r.RoleID == roleID {
+				channelID, messageID, emoji = m.ChannelID, m.MessageID, r.Emoji
+				break
+			}
+		}
+		break
+	}
+	gs.Unlock()
+	if emoji == "" {
+		return
+	}
+	if err := s.MessageReactionRemove(channelID, messageID, emoji, userID); err != nil {
+		log.Printf("[ReactionRole] Could not remove %s's reaction %s on %s: %v", userID, emoji, messageID, err)
+	}
+}
+
+// reactionMenuIndex returns the index of the reaction menu bound to messageID, or -1.
+// The caller must hold the guild lock.
+func reactionMenuIndex(gs *config.GuildState, messageID string) int {
+	for idx := range gs.RoleMenus {
+		if gs.RoleMenus[idx].Reactions && gs.RoleMenus[idx].MessageID == messageID {
+			return idx
+		}
+	}
+	return -1
+}
+
+// normalizeReactionEmoji converts user input into the form the reaction API and
+// gateway events use: "name:id" for custom emoji, the raw character otherwise.
+func normalizeReactionEmoji(input string) string {
+	input = strings.TrimSpace(input)
+	if m := customEmojiRe.FindStringSubmatch(input); m != nil {
+		return m[1] + ":" + m[2]
+	}
+	if strings.HasPrefix(input, "<") || strings.ContainsAny(input, " \t") {
+		return ""
+	}
+	return input
+}
+
+// displayReactionEmoji turns a stored emoji back into something Discord renders.
+func displayReactionEmoji(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
+
+func memberHasRole(member *discordgo.Member, roleID string) bool {
+	for _, rid := range member.Roles {
+		if rid == roleID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reportTimeout is how long the "Timeout author" button times a member out.
+const (
+	reportTimeout    = 10 * time.Minute
+	reportTimeoutStr = "10m"
+)
+
+// pendingReportTTL is how long a captured message waits for its reason modal.
+const pendingReportTTL = 15 * time.Minute
+
+// pendingReport is a message captured when "Report message" is used, held
+// until the reporter submits the reason modal.
+type pendingReport struct {
+	channelID string
+	authorID  string
+	content   string
+	at        time.Time
+}
+
+var (
+	pendingReports   = make(map[string]pendingReport) // reporterID:messageID
+	pendingReportsMu sync.Mutex
+)
+
+func reportCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name: "Report message",
+			Type: discordgo.MessageApplicationCommand,
+		},
+		{
+			Name:                     "reports",
+			Description:              "Configure the message report queue",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "channel",
+					Description: "Send reports to this staff channel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Staff channel for reports", Required: true, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+					},
+				},
+				{
+					Name:        "disable",
+					Description: "Stop accepting reports",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+func handleReportsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	gs := storage.GetGuild(i.GuildID)
+
+	switch sub.Name {
+	case "channel":
+		ch := subOptMap(sub.Options)["channel"].ChannelValue(s)
+		gs.Lock()
+		gs.Reports.ChannelID = ch.ID
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("report_channel_set", "channel_id", ch.ID), true)
+
+	case "disable":
+		gs.Lock()
+		gs.Reports.ChannelID = ""
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("report_disabled"), true)
+	}
+}
+
+// handleReportMessage captures the targeted message and asks for a reason.
+func handleReportMessage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	channelID := gs.Reports.ChannelID
+	gs.Unlock()
+	if channelID == "" {
+		respond(s, i, lang.T("report_not_enabled"), true)
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	var msg *discordgo.Message
+	if data.Resolved != nil {
+		msg = data.Resolved.Messages[data.TargetID]
+	}
+	if msg == nil || msg.Author == nil {
+		respond(s, i, lang.T("report_unavailable"), true)
+		return
+	}
+	if msg.Author.ID == i.Member.User.ID || msg.Author.ID == s.State.User.ID {
+		respond(s, i, lang.T("report_own_message"), true)
+		return
+	}
+
+	content := msg.Content
+	for _, a := range msg.Attachments {
+		content += "\n" + a.URL
+	}
+	if strings.TrimSpace(content) == "" {
+		content = lang.T("report_no_content")
+	}
+
+	pendingReportsMu.Lock()
+	for key, p := range pendingReports {
+		if time.Since(p.at) > pendingReportTTL {
+			delete(pendingReports, key)
+		}
+	}
+	pendingReports[i.Member.User.ID+":"+msg.ID] = pendingReport{
+		channelID: i.ChannelID,
+		authorID:  msg.Author.ID,
+		content:   content,
+		at:        time.Now(),
+	}
+	pendingReportsMu.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "report_modal:" + msg.ID,
+			Title:    lang.T("report_modal_title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "reason",
+						Label:     lang.T("report_modal_label"),
+						Style:     discordgo.TextInputParagraph,
+						Required:  true,
+						MaxLength: 500,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[Reports] Failed to open modal for %s: %v", msg.ID, err)
+	}
+}
+
+// HandleReportModal files a report and posts it to the reports channel.
+func HandleReportModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	messageID := strings.TrimPrefix(data.CustomID, "report_modal:")
+	reporter := i.Member.User
+
+	key := reporter.ID + ":" + messageID
+	pendingReportsMu.Lock()
+	p, ok := pendingReports[key]
+	delete(pendingReports, key)
+	pendingReportsMu.Unlock()
+	if !ok {
+		respond(s, i, lang.T("report_expired"), true)
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	channelID := gs.Reports.ChannelID
+	if channelID == "" {
+		gs.Unlock()
+		respond(s, i, lang.T("report_not_enabled"), true)
+		return
+	}
+	gs.Reports.Counter++
+	r := config.MessageReport{
+		ID:         gs.Reports.Counter,
+		ChannelID:  p.channelID,
+		MessageID:  messageID,
+		AuthorID:   p.authorID,
+		ReporterID: reporter.ID,
+		Reason:     strings.TrimSpace(modalValue(data, "reason")),
+		Content:    p.content,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+	gs.Unlock()
+
+	button := func(label, action string, style discordgo.ButtonStyle) discordgo.Button {
+		return discordgo.Button{Label: label, Style: style, CustomID: fmt.Sprintf("report:%s:%d", action, r.ID)}
+	}
+	posted, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{reportEmbed(i.GuildID, r)},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				button(lang.T("report_btn_delete"), "delete", discordgo.DangerButton),
+				button(lang.T("report_btn_warn"), "warn", discordgo.PrimaryButton),
+				button(lang.T("report_btn_timeout"), "timeout", discordgo.PrimaryButton),
+				button(lang.T("report_btn_dismiss"), "dismiss", discordgo.SecondaryButton),
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("[Reports] Failed to post report #%d: %v", r.ID, err)
+		respond(s, i, lang.T("report_unavailable"), true)
+		return
+	}
+	r.ReportMessageID = posted.ID
+
+	gs.Lock()
+	gs.OpenReports = append(gs.OpenReports, r)
+	gs.Unlock()
+	_ = gs.Save()
+
+	respond(s, i, lang.T("report_sent"), true)
+}
+
+func reportEmbed(guildID string, r config.MessageReport) *discordgo.MessageEmbed {
+	content := r.Content
+	if runes := []rune(content); len(runes) > 4000 {
+		content = string(runes[:4000]) + "…"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       lang.T("report_embed_title", "id", strconv.Itoa(r.ID)),
+		Description: content,
+		Color:       0xFEE75C,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: lang.T("report_author_field"), Value: "<@" + r.AuthorID + ">", Inline: true},
+			{Name: lang.T("report_reporter_field"), Value: "<@" + r.ReporterID + ">", Inline: true},
+			{Name: lang.T("report_message_field"), Value: fmt.Sprintf("[%s](https://discord.com/channels/%s/%s/%s)", lang.T("report_jump"), guildID, r.ChannelID, r.MessageID), Inline: true},
+			{Name: lang.T("modlog_reason_field"), Value: r.Reason},
+		},
+		Timestamp: r.CreatedAt,
+	}
+}
+
+// HandleReportAction runs a staff decision on a report and resolves it.
+func HandleReportAction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	action := parts[1]
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case "delete", "warn", "timeout", "dismiss":
+	default:
+		return
+	}
+
+	// Warning or timing out takes several REST calls, easily more than three
+	// seconds; acknowledge now and edit the report message once done.
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+
+	// Take the report off the open list before acting so a second moderator
+	// clicking at the same time finds it resolved.
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := -1
+	for n, r := range gs.OpenReports {
+		if r.ID == id {
+			idx = n
+			break
+		}
+	}
+	if idx < 0 {
+		gs.Unlock()
+		followup(s, i, lang.T("report_already_resolved"))
+		return
+	}
+	r := gs.OpenReports[idx]
+	gs.OpenReports = append(gs.OpenReports[:idx], gs.OpenReports[idx+1:]...)
+	gs.Unlock()
+	_ = gs.Save()
+
+	// reopen puts the report back when its action fails.
+	reopen := func() {
+		gs.Lock()
+		gs.OpenReports = append(gs.OpenReports, r)
+		gs.Unlock()
+		_ = gs.Save()
+	}
+
+	mod := i.Member.User
+	reason := lang.T("report_action_reason", "id", strconv.Itoa(r.ID), "reason", r.Reason)
+
+	switch action {
+	case "delete":
+		forgetGhostPing(r.MessageID)
+		if err := s.ChannelMessageDelete(r.ChannelID, r.MessageID); err != nil {
+			reopen()
+			followup(s, i, lang.T("report_delete_failed", "error", err.Error()))
+			return
+		}
+		logModEvent(s, i.GuildID, lang.T("report_log_title"),
+			lang.T("report_log_deleted", "mod_id", mod.ID, "id", strconv.Itoa(r.ID), "author_id", r.AuthorID, "channel_id", r.ChannelID),
+			0xFEE75C)
+
+	case "warn", "timeout":
+		if problem := checkModTarget(s, i, r.AuthorID); problem != "" {
+			reopen()
+			followup(s, i, problem)
+			return
+		}
+		author, err := s.User(r.AuthorID)
+		if err != nil {
+			reopen()
+			followup(s, i, lang.T("report_unavailable"))
+			return
+		}
+		if action == "warn" {
+			warnMember(s, i.GuildID, author, mod, reason, false)
+		} else if err := timeoutMember(s, i.GuildID, author, mod, reportTimeout, reportTimeoutStr, reason, false); err != nil {
+			reopen()
+			followup(s, i, lang.T("mod_mute_failed", "error", err.Error()))
+			return
+		}
+	}
+
+	embed := reportEmbed(i.GuildID, r)
+	embed.Color = 0x57F287
+	if action == "dismiss" {
+		embed.Color = 0x99AAB5
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  lang.T("report_resolution_field"),
+		Value: lang.T("report_resolved_"+action, "mod_id", mod.ID),
+	})
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &[]discordgo.MessageComponent{},
+	})
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// savedRolesTTL is how long the roles of a member who left are kept. Punishment
+// roles are tracked separately in PunishedRoles and never expire.
+const savedRolesTTL = 180 * 24 * time.Hour
+
+func stickyRoleCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "stickyroles",
+			Description:              "Give roles back to members who leave and rejoin",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "enable",
+					Description: "Restore allowlisted roles on rejoin",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "disable",
+					Description: "Stop restoring allowlisted roles (punishment roles are still restored)",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "add",
+					Description: "Add a role to the restore allowlist",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to restore on rejoin", Required: true},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Remove a role from the restore allowlist",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to stop restoring", Required: true},
+					},
+				},
+				{
+					Name:        "status",
+					Description: "Show which roles are restored on rejoin",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	}
+}
+
+// RegisterStickyRoles saves members' roles when they leave and restores the
+// allowlisted and punishment roles when they come back.
+func RegisterStickyRoles(s *discordgo.Session) {
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		saveMemberRoles(m)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		trackPunishmentRoles(m.GuildID, m.Member)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		restoreMemberRoles(s, m.GuildID, m.User.ID)
+	})
+}
+
+func handleStickyRolesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	gs := storage.GetGuild(i.GuildID)
+
+	switch sub.Name {
+	case "enable":
+		gs.Lock()
+		gs.StickyRoles.Enabled = true
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("sticky_enabled"), true)
+
+	case "disable":
+		gs.Lock()
+		gs.StickyRoles.Enabled = false
+		gs.Unlock()
+		_ = gs.Save()
+		respond(s, i, lang.T("sticky_disabled"), true)
+
+	case "add":
+		role := subOptMap(sub.Options)["role"].RoleValue(s, i.GuildID)
+		gs.Lock()
+		exists := false
+		for _, rid := range gs.StickyRoles.RoleIDs {
+			if rid == role.ID {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			gs.StickyRoles.RoleIDs = append(gs.StickyRoles.RoleIDs, role.ID)
+		}
+		gs.Unlock()
+		_ = gs.Save()
+
+		if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
+			respond(s, i, lang.T("sticky_role_added_warning", "role_id", role.ID, "warning", w), true)
+			return
+		}
+		respond(s, i, lang.T("sticky_role_added", "role_id", role.ID), true)
+
+	case "remove":
+		role := subOptMap(sub.Options)["role"].RoleValue(s, i.GuildID)
+		gs.Lock()
+		found := false
+		kept := make([]string, 0, len(gs.StickyRoles.RoleIDs))
+		for _, rid := range gs.StickyRoles.RoleIDs {
+			if rid == role.ID {
+				found = true
+				continue
+			}
+			kept = append(kept, rid)
+		}
+		gs.StickyRoles.RoleIDs = kept
+		gs.Unlock()
+		if !found {
+			respond(s, i, lang.T("sticky_role_not_listed", "role_id", role.ID), true)
+			return
+		}
+		_ = gs.Save()
+		respond(s, i, lang.T("sticky_role_removed", "role_id", role.ID), true)
+
+	case "status":
+		gs.Lock()
+		st := gs.StickyRoles
+		st.RoleIDs = append([]string(nil), st.RoleIDs...)
+		gs.Unlock()
+
+		state := lang.T("sticky_state_disabled")
+		if st.Enabled {
+			state = lang.T("sticky_state_enabled")
+		}
+		respond(s, i, lang.T("sticky_status",
+			"state", state,
+			"roles", formatRoleMentions(st.RoleIDs),
+			"punishment", formatRoleMentions(punishmentRoleIDs(gs)),
+		), true)
+	}
+}
+
+// trackPunishmentRoles records which punishment roles a member holds after
+// every member update, which carries the full role list whether or not the
+// member was cached.
+func trackPunishmentRoles(guildID string, member *discordgo.Member) {
+	if member == nil || member.User == nil || member.User.Bot {
+		return
+	}
+	gs := storage.GetGuild(guildID)
+	punishment := make(map[string]bool)
+	for _, rid := range punishmentRoleIDs(gs) {
+		punishment[rid] = true
+	}
+	var held []string
+	for _, rid := range member.Roles {
+		if punishment[rid] {
+			held = append(held, rid)
+		}
+	}
+
+	gs.Lock()
+	if slices.Equal(gs.PunishedRoles[member.User.ID], held) {
+		gs.Unlock()
+		return
+	}
+	if len(held) == 0 {
+		delete(gs.PunishedRoles, member.User.ID)
+	} else {
+		gs.PunishedRoles[member.User.ID] = held
+	}
+	gs.Unlock()
+	_ = gs.Save()
+}
+
+// saveMemberRoles records the roles of a departing member. The gateway payload
+// only carries the user, so the cached member from the state is preferred;
+// uncached members still keep their punishment roles through PunishedRoles.
+func saveMemberRoles(m *discordgo.GuildMemberRemove) {
+	if m.User == nil || m.User.Bot {
+		return
+	}
+	member := m.BeforeDelete
+	if member == nil {
+		member = m.Member
+	}
+	if member == nil || len(member.Roles) == 0 {
+		return
+	}
+
+	gs := storage.GetGuild(m.GuildID)
+	gs.Lock()
+	pruneSavedRoles(gs)
+	gs.SavedRoles[m.User.ID] = append([]string(nil), member.Roles...)
+	gs.SavedRolesAt[m.User.ID] = time.Now().Format(time.RFC3339)
+	gs.Unlock()
+	_ = gs.Save()
+}
+
+// pruneSavedRoles drops saved roles older than savedRolesTTL. Entries saved
+// before timestamps were recorded are dated now, so they expire in turn. The
+// caller must hold the guild lock.
+func pruneSavedRoles(gs *config.GuildState) {
+	cutoff := time.Now().Add(-savedRolesTTL)
+	for userID := range gs.SavedRoles {
+		at, ok := gs.SavedRolesAt[userID]
+		if !ok {
+			gs.SavedRolesAt[userID] = time.Now().Format(time.RFC3339)
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, at); err == nil && t.Before(cutoff) {
+			delete(gs.SavedRoles, userID)
+			delete(gs.SavedRolesAt, userID)
+		}
+	}
+}
+
+// restoreMemberRoles gives a returning member back their saved punishment roles
+// and, when sticky roles are enabled, any allowlisted roles they had.
+func restoreMemberRoles(s *discordgo.Session, guildID, userID string) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	saved, ok := gs.SavedRoles[userID]
+	if ok {
+		delete(gs.SavedRoles, userID)
+		delete(gs.SavedRolesAt, userID)
+	}
+	// PunishedRoles is left in place; the member update that follows the
+	// restore keeps it current.
+	punished := gs.PunishedRoles[userID]
+	saved = append(append([]string(nil), saved...), punished...)
+	sticky := gs.StickyRoles
+	sticky.RoleIDs = append([]string(nil), sticky.RoleIDs...)
+	gs.Unlock()
+	if len(saved) == 0 {
+		return
+	}
+	if ok {
+		_ = gs.Save()
+	}
+
+	allowed := make(map[string]bool)
+	for _, rid := range punishmentRoleIDs(gs) {
+		allowed[rid] = true
+	}
+	if sticky.Enabled {
+		for _, rid := range sticky.RoleIDs {
+			allowed[rid] = true
+		}
+	}
+
+	restored := 0
+	seen := make(map[string]bool)
+	for _, rid := range saved {
+		if !allowed[rid] || seen[rid] {
+			continue
+		}
+		seen[rid] = true
+		if err := s.GuildMemberRoleAdd(guildID, userID, rid); err != nil {
+			log.Printf("[StickyRoles] Failed to restore role %s to %s in guild %s: %v", rid, userID, guildID, err)
+			continue
+		}
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("[StickyRoles] Restored %d role(s) to %s in guild %s", restored, userID, guildID)
+	}
+}
+
+// punishmentRoleIDs lists the roles that are always restored on rejoin.
+func punishmentRoleIDs(gs *config.GuildState) []string {
+	cfg := storage.Cfg
+	gs.Lock()
+	mute := config.EffectiveMuteRole(cfg, gs)
+	gs.Unlock()
+
+	ids := make([]string, 0, len(cfg.Moderation.PunishmentRoles)+1)
+	if mute != "" {
+		ids = append(ids, mute)
+	}
+	ids = append(ids, cfg.Moderation.PunishmentRoles...)
+	return ids
+}
+
+func formatRoleMentions(ids []string) string {
+	if len(ids) == 0 {
+		return "—"
+	}
+	parts := make([]string, len(ids))
+	for idx, rid := range ids {
+		parts[idx] = fmt.Sprintf("<@&%s>", rid)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// tempRoleTimer is a scheduled expiry. gen tells a timer that fired apart
+// from the one that replaced it when the role was granted again.
+type tempRoleTimer struct {
+	timer *time.Timer
+	gen   uint64
+}
+
+var (
+	tempRoleTimers   = make(map[string]tempRoleTimer)
+	tempRoleTimersMu sync.Mutex
+	tempRoleTimerGen uint64
+)
+
+func tempRoleCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "temprole",
+			Description:              "Give roles that are removed automatically after a while",
+			DefaultMemberPermissions: &modPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Description: "Give a member a role for a limited time",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Member to give the role to", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role to give", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "How long to keep it (e.g. 30m, 12h, 7d)", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason for the role"},
+					},
+				},
+				{
+					Name:        "remove",
+					Description: "Remove a temporary role now",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Member holding the role", Required: true},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Temporary role to remove", Required: true},
+					},
+				},
+				{
+					Name:        "list",
+					Description: "List active temporary roles",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Only show this member"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func handleTempRoleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "add":
+		handleTempRoleAdd(s, i, sub.Options)
+	case "remove":
+		handleTempRoleRemove(s, i, sub.Options)
+	case "list":
+		handleTempRoleList(s, i, sub.Options)
+	}
+}
+
+func handleTempRoleAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	target := om["user"].UserValue(s)
+	role := om["role"].RoleValue(s, i.GuildID)
+	durStr := om["duration"].StringValue()
+	reason := optStr(om, "reason", "No reason provided")
+
+	dur, err := parseDuration(durStr)
+	if err != nil || dur <= 0 {
+		respond(s, i, lang.T("temprole_invalid_duration"), true)
+		return
+	}
+	tc, problem := newTargetCheck(s, i)
+	if problem == "" {
+		problem = tc.check(target.ID)
+	}
+	if problem != "" {
+		respond(s, i, problem, true)
+		return
+	}
+	if tc.modID != tc.ownerID && role.Position >= tc.modTop {
+		respond(s, i, lang.T("temprole_role_above_you", "role_id", role.ID), true)
+		return
+	}
+	if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
+		respond(s, i, lang.T("temprole_hierarchy", "warning", w), true)
+		return
+	}
+
+	if err := s.GuildMemberRoleAdd(i.GuildID, target.ID, role.ID); err != nil {
+		respond(s, i, lang.T("temprole_add_failed", "error", err.Error()), true)
+		return
+	}
+	expiresAt := addTempRole(s, i.GuildID, target.ID, role.ID, i.Member.User.ID, reason, "", dur)
+
+	respond(s, i, lang.T("temprole_added",
+		"user", target.Username,
+		"role_id", role.ID,
+		"timestamp", fmt.Sprintf("%d", expiresAt.Unix()),
+	), false)
+	logModAction(s, i.GuildID, fmt.Sprintf("Temp Role (%s)", role.Name), target, i.Member.User, reason, durStr)
+}
+
+func handleTempRoleRemove(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	target := om["user"].UserValue(s)
+	role := om["role"].RoleValue(s, i.GuildID)
+
+	if !removeTempRoleEntry(i.GuildID, target.ID, role.ID) {
+		respond(s, i, lang.T("temprole_not_found", "user", target.Username, "role_id", role.ID), true)
+		return
+	}
+	cancelTempRoleTimer(i.GuildID, target.ID, role.ID)
+
+	if err := s.GuildMemberRoleRemove(i.GuildID, target.ID, role.ID); err != nil {
+		respond(s, i, lang.T("temprole_remove_failed", "error", err.Error()), true)
+		return
+	}
+	respond(s, i, lang.T("temprole_removed", "user", target.Username, "role_id", role.ID), false)
+	logModAction(s, i.GuildID, fmt.Sprintf("Temp Role Removed (%s)", role.Name), target, i.Member.User, "", "")
+}
+
+func handleTempRoleList(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	filter := ""
+	if u, ok := om["user"]; ok {
+		filter = u.UserValue(s).ID
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	roles := make([]config.TempRole, 0, len(gs.TempRoles))
+	for _, tr := range gs.TempRoles {
+		if filter == "" || tr.UserID == filter {
+			roles = append(roles, tr)
+		}
+	}
+	gs.Unlock()
+
+	if len(roles) == 0 {
+		respond(s, i, lang.T("temprole_none"), true)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lang.T("temprole_list_header", "count", fmt.Sprintf("%d", len(roles))))
+	for _, tr := range roles {
+		expiresAt, _ := time.Parse(time.RFC3339, tr.ExpiresAt)
+		sb.WriteString(lang.T("temprole_list_entry",
+			"user_id", tr.UserID,
+			"role_id", tr.RoleID,
+			"timestamp", fmt.Sprintf("%d", expiresAt.Unix()),
+		))
+	}
+	respond(s, i, sb.String(), true)
+}
+
+// addTempRole records a temporary grant (replacing any existing expiry for the
+// same member and role) and schedules its removal. The role itself must already
+// have been given by the caller. menuID is empty unless a role menu gave it.
+func addTempRole(s *discordgo.Session, guildID, userID, roleID, grantedBy, reason, menuID string, dur time.Duration) time.Time {
+	expiresAt := time.Now().Add(dur)
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	tr := config.TempRole{
+		UserID:    userID,
+		RoleID:    roleID,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		GrantedBy: grantedBy,
+		Reason:    reason,
+		MenuID:    menuID,
+	}
+	replaced := false
+	for idx := range gs.TempRoles {
+		if gs.TempRoles[idx].UserID == userID && gs.TempRoles[idx].RoleID == roleID {
+			gs.TempRoles[idx] = tr
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		gs.TempRoles = append(gs.TempRoles, tr)
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	cancelTempRoleTimer(guildID, userID, roleID)
+	scheduleTempRole(s, guildID, userID, roleID, dur)
+	return expiresAt
+}
+
+// removeTempRoleEntry forgets a temporary grant without touching the member's roles.
+func removeTempRoleEntry(guildID, userID, roleID string) bool {
+	_, found := takeTempRoleEntry(guildID, userID, roleID)
+	return found
+}
+
+// takeTempRoleEntry forgets a temporary grant and returns it.
+func takeTempRoleEntry(guildID, userID, roleID string) (config.TempRole, bool) {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	var removed config.TempRole
+	found := false
+	kept := make([]config.TempRole, 0, len(gs.TempRoles))
+	for _, tr := range gs.TempRoles {
+		if tr.UserID == userID && tr.RoleID == roleID {
+			removed = tr
+			found = true
+			continue
+		}
+		kept = append(kept, tr)
+	}
+	gs.TempRoles = kept
+	gs.Unlock()
+	if found {
+		_ = gs.Save()
+	}
+	return removed, found
+}
+
+func scheduleTempRole(s *discordgo.Session, guildID, userID, roleID string, dur time.Duration) {
+	key := guildID + ":" + userID + ":" + roleID
+	tempRoleTimersMu.Lock()
+	defer tempRoleTimersMu.Unlock()
+	tempRoleTimerGen++
+	gen := tempRoleTimerGen
+	t := time.AfterFunc(dur, func() {
+		// Ignore a timer that was replaced while it was firing; the new one
+		// owns the grant now.
+		tempRoleTimersMu.Lock()
+		cur, ok := tempRoleTimers[key]
+		if !ok || cur.gen != gen {
+			tempRoleTimersMu.Unlock()
+			return
+		}
+		delete(tempRoleTimers, key)
+		tempRoleTimersMu.Unlock()
+		expireTempRole(s, guildID, userID, roleID)
+	})
+	tempRoleTimers[key] = tempRoleTimer{timer: t, gen: gen}
+}
+
+func cancelTempRoleTimer(guildID, userID, roleID string) {
+	key := guildID + ":" + userID + ":" + roleID
+	tempRoleTimersMu.Lock()
+	if t, ok := tempRoleTimers[key]; ok {
+		t.timer.Stop()
+		delete(tempRoleTimers, key)
+	}
+	tempRoleTimersMu.Unlock()
+}
+
+// expireTempRole removes a grant whose time is up. Timers clear their own map
+// entry before calling it.
+func expireTempRole(s *discordgo.Session, guildID, userID, roleID string) {
+	tr, ok := takeTempRoleEntry(guildID, userID, roleID)
+	if !ok {
+		return
+	}
+
+	if err := s.GuildMemberRoleRemove(guildID, userID, roleID); err != nil {
+		log.Printf("[TempRole] Failed to remove role %s from %s in guild %s: %v", roleID, userID, guildID, err)
+	} else {
+		log.Printf("[TempRole] Expired role %s for %s in guild %s", roleID, userID, guildID)
+	}
+	// A leftover reaction would hand the role back on the next resync.
+	if tr.MenuID != "" {
+		removeMenuReaction(s, guildID, tr.MenuID, userID, roleID)
+	}
+
+	roleName := roleID
+	if r, err := s.State.Role(guildID, roleID); err == nil {
+		roleName = r.Name
+	}
+	target, err := s.User(userID)
+	if err != nil {
+		target = &discordgo.User{ID: userID, Username: userID}
+	}
+	logModAction(s, guildID, fmt.Sprintf("Temp Role Expired (%s)", roleName), target, s.State.User, "", "")
+}
+
+// RestoreTempRoleTimers reschedules pending expiries after a restart and removes
+// roles whose expiry passed while the bot was offline.
+func RestoreTempRoleTimers(s *discordgo.Session, gs *config.GuildState) {
+	gs.Lock()
+	guildID := gs.GuildID
+	roles := make([]config.TempRole, len(gs.TempRoles))
+	copy(roles, gs.TempRoles)
+	gs.Unlock()
+
+	for _, tr := range roles {
+		expiresAt, err := time.Parse(time.RFC3339, tr.ExpiresAt)
+		if err != nil {
+			continue
+		}
+		remaining := time.Until(expiresAt)
+		if remaining <= 0 {
+			go expireTempRole(s, guildID, tr.UserID, tr.RoleID)
+		} else {
+			scheduleTempRole(s, guildID, tr.UserID, tr.RoleID, remaining)
+		}
+	}
+}
+
+// startMenuRoleExpiry schedules removal of a role picked from a menu entry that
+// has a Duration. It returns false when the entry is permanent.
+func startMenuRoleExpiry(s *discordgo.Session, guildID string, user *discordgo.User, entry config.RoleMenuEntry, menuID string) (time.Time, bool) {
+	if entry.Duration == "" {
+		return time.Time{}, false
+	}
+	dur, err := parseDuration(entry.Duration)
+	if err != nil || dur <= 0 {
+		log.Printf("[TempRole] Menu %s has an invalid duration %q for role %s", menuID, entry.Duration, entry.RoleID)
+		return time.Time{}, false
+	}
+	reason := "Role menu " + menuID
+	expiresAt := addTempRole(s, guildID, user.ID, entry.RoleID, user.ID, reason, menuID, dur)
+
+	roleName := entry.RoleID
+	if r, err := s.State.Role(guildID, entry.RoleID); err == nil {
+		roleName = r.Name
+	}
+	logModAction(s, guildID, fmt.Sprintf("Temp Role (%s)", roleName), user, s.State.User, reason, entry.Duration)
+	return expiresAt, true
+}
+
+// clearMenuRoleExpiry drops a pending expiry when a member removes the role themselves.
+func clearMenuRoleExpiry(guildID, userID, roleID string) {
+	if removeTempRoleEntry(guildID, userID, roleID) {
+		cancelTempRoleTimer(guildID, userID, roleID)
+	}
+}
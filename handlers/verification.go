@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/lang"
+	"discord-bot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxVerifyAttempts is how many wrong captcha answers a member may give before
+// being kicked.
+const maxVerifyAttempts = 3
+
+// captchaAlphabet leaves out characters that are easy to confuse (0/O, 1/I/L).
+const captchaAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+var (
+	verifyTimers   = make(map[string]*time.Timer)
+	verifyTimersMu sync.Mutex
+)
+
+func verificationCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:                     "verification",
+			Description:              "Make new members pass a challenge before they get access",
+			DefaultMemberPermissions: &adminPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "setup",
+					Description: "Enable verification and post the verify panel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Channel where members verify", Required: true, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "quarantine_role", Description: "Role held by members until they verify", Required: true},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "Challenge type (default: button)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Button only", Value: "button"},
+								{Name: "Text captcha", Value: "text"},
+								{Name: "Math captcha", Value: "math"},
+							},
+						},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "timeout", Description: "Kick members who don't verify in time (e.g. 10m, 1h)"},
+					},
+				},
+				{
+					Name:        "disable",
+					Description: "Disable verification and let pending members in",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "status",
+					Description: "Show the verification settings and pending members",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        "approve",
+					Description: "Verify a member manually",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Member to verify", Required: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func handleVerificationCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i) {
+		respond(s, i, lang.T("no_permission"), true)
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "setup":
+		handleVerificationSetup(s, i, sub.Options)
+	case "disable":
+		handleVerificationDisable(s, i)
+	case "status":
+		handleVerificationStatus(s, i)
+	case "approve":
+		target := subOptMap(sub.Options)["user"].UserValue(s)
+		deferEphemeral(s, i)
+		if !passVerification(s, i.GuildID, target.ID, i.Member.User) {
+			followup(s, i, lang.T("verify_not_pending_user", "user", target.Username))
+			return
+		}
+		followup(s, i, lang.T("verify_approved", "user", target.Username))
+	}
+}
+
+func handleVerificationSetup(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	om := subOptMap(opts)
+	ch := om["channel"].ChannelValue(s)
+	role := om["quarantine_role"].RoleValue(s, i.GuildID)
+	mode := optStr(om, "mode", "button")
+	timeout := strings.TrimSpace(optStr(om, "timeout", ""))
+
+	if timeout != "" {
+		if dur, err := parseDuration(timeout); err != nil || dur <= 0 {
+			respond(s, i, lang.T("verify_invalid_timeout"), true)
+			return
+		}
+	}
+	if w := checkBotRoleHierarchy(s, i.GuildID, role); w != "" {
+		respond(s, i, lang.T("verify_hierarchy", "warning", w), true)
+		return
+	}
+
+	msg, err := s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       lang.T("verify_panel_title"),
+			Description: lang.T("verify_panel_desc"),
+			Color:       0x5865F2,
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    lang.T("verify_button"),
+					Style:    discordgo.SuccessButton,
+					CustomID: "verify_start",
+					Emoji:    &discordgo.ComponentEmoji{Name: "✅"},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		respond(s, i, lang.T("verify_post_failed", "error", err.Error()), true)
+		return
+	}
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	gs.Verification = config.VerificationState{
+		Enabled:          true,
+		ChannelID:        ch.ID,
+		MessageID:        msg.ID,
+		QuarantineRoleID: role.ID,
+		Mode:             mode,
+		Timeout:          timeout,
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	respond(s, i, lang.T("verify_setup_done", "channel_id", ch.ID, "role_id", role.ID, "mode", mode), true)
+}
+
+func handleVerificationDisable(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	gs.Verification.Enabled = false
+	pending := make([]string, 0, len(gs.PendingVerifications))
+	for _, p := range gs.PendingVerifications {
+		pending = append(pending, p.UserID)
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	deferEphemeral(s, i)
+	for _, userID := range pending {
+		passVerification(s, i.GuildID, userID, i.Member.User)
+	}
+	followup(s, i, lang.T("verify_disabled", "count", strconv.Itoa(len(pending))))
+}
+
+func handleVerificationStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	v := gs.Verification
+	pending := len(gs.PendingVerifications)
+	gs.Unlock()
+
+	if !v.Enabled {
+		respond(s, i, lang.T("verify_status_disabled"), true)
+		return
+	}
+	timeout := v.Timeout
+	if timeout == "" {
+		timeout = lang.T("verify_timeout_none")
+	}
+	respond(s, i, lang.T("verify_status",
+		"channel_id", v.ChannelID,
+		"role_id", v.QuarantineRoleID,
+		"mode", v.Mode,
+		"timeout", timeout,
+		"pending", strconv.Itoa(pending),
+	), true)
+}
+
+// startVerification quarantines a new member when verification is enabled. It
+// returns false when the member should get their join roles right away.
+func startVerification(s *discordgo.Session, guildID string, member *discordgo.Member) bool {
+	if member.User == nil || member.User.Bot {
+		return false
+	}
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	v := gs.Verification
+	gs.Unlock()
+	if !v.Enabled || v.QuarantineRoleID == "" {
+		return false
+	}
+
+	if err := s.GuildMemberRoleAdd(guildID, member.User.ID, v.QuarantineRoleID); err != nil {
+		log.Printf("[Verify] Failed to quarantine %s in guild %s: %v", member.User.ID, guildID, err)
+	}
+
+	p := config.PendingVerification{UserID: member.User.ID}
+	var dur time.Duration
+	if v.Timeout != "" {
+		dur, _ = parseDuration(v.Timeout)
+	}
+	if dur > 0 {
+		p.ExpiresAt = time.Now().Add(dur).Format(time.RFC3339)
+	}
+
+	gs.Lock()
+	replaced := false
+	for idx := range gs.PendingVerifications {
+		if gs.PendingVerifications[idx].UserID == p.UserID {
+			gs.PendingVerifications[idx] = p
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		gs.PendingVerifications = append(gs.PendingVerifications, p)
+	}
+	gs.Unlock()
+	_ = gs.Save()
+
+	if dur > 0 {
+		scheduleVerifyTimeout(s, guildID, member.User.ID, dur)
+	}
+	return true
+}
+
+// HandleVerifyStart runs when a member clicks the verify button. Button mode
+// passes them straight away; captcha modes open a modal with a fresh challenge.
+func HandleVerifyStart(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := i.Member.User.ID
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	mode := gs.Verification.Mode
+	idx := pendingVerificationIndex(gs, userID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("verify_not_pending"), true)
+		return
+	}
+	if mode == "button" || mode == "" {
+		gs.Unlock()
+		// Role edits can be slow; answer first so the button doesn't fail.
+		deferEphemeral(s, i)
+		passVerification(s, i.GuildID, userID, i.Member.User)
+		followup(s, i, lang.T("verify_success"))
+		return
+	}
+
+	prompt, answer := generateCaptcha(mode)
+	gs.PendingVerifications[idx].Answer = answer
+	gs.Unlock()
+	_ = gs.Save()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "verify_modal",
+			Title:    lang.T("verify_modal_title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "answer",
+						Label:     prompt,
+						Style:     discordgo.TextInputShort,
+						Required:  true,
+						MaxLength: 16,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[Verify] Failed to open modal for %s: %v", userID, err)
+	}
+}
+
+// HandleVerifyModal checks a captcha answer.
+func HandleVerifyModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	given := strings.ToUpper(strings.TrimSpace(modalValue(i.ModalSubmitData(), "answer")))
+
+	gs := storage.GetGuild(i.GuildID)
+	gs.Lock()
+	idx := pendingVerificationIndex(gs, user.ID)
+	if idx < 0 {
+		gs.Unlock()
+		respond(s, i, lang.T("verify_not_pending"), true)
+		return
+	}
+	p := &gs.PendingVerifications[idx]
+	if p.Answer != "" && given == p.Answer {
+		gs.Unlock()
+		deferEphemeral(s, i)
+		passVerification(s, i.GuildID, user.ID, user)
+		followup(s, i, lang.T("verify_success"))
+		return
+	}
+	p.Attempts++
+	p.Answer = ""
+	attempts := p.Attempts
+	gs.Unlock()
+	_ = gs.Save()
+
+	logModEvent(s, i.GuildID, lang.T("verify_log_failed_title"),
+		lang.T("verify_log_failed", "user_id", user.ID, "attempts", strconv.Itoa(attempts), "max", strconv.Itoa(maxVerifyAttempts)),
+		0xFEE75C)
+
+	if attempts >= maxVerifyAttempts {
+		respond(s, i, lang.T("verify_too_many"), true)
+		failVerification(s, i.GuildID, user.ID, lang.T("verify_reason_attempts"))
+		return
+	}
+	respond(s, i, lang.T("verify_wrong", "remaining", strconv.Itoa(maxVerifyAttempts-attempts)), true)
+}
+
+// passVerification lifts the quarantine and hands out the normal join roles.
+// It returns false when the member wasn't waiting on verification.
+func passVerification(s *discordgo.Session, guildID, userID string, by *discordgo.User) bool {
+	if !removePendingVerification(guildID, userID) {
+		return false
+	}
+	cancelVerifyTimer(guildID, userID)
+
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	roleID := gs.Verification.QuarantineRoleID
+	gs.Unlock()
+	if roleID != "" {
+		if err := s.GuildMemberRoleRemove(guildID, userID, roleID); err != nil {
+			log.Printf("[Verify] Failed to remove quarantine role from %s in guild %s: %v", userID, guildID, err)
+		}
+	}
+
+	if member, err := s.GuildMember(guildID, userID); err == nil {
+		AssignJoinRole(s, guildID, member)
+	} else {
+		log.Printf("[Verify] Failed to fetch member %s for join roles: %v", userID, err)
+	}
+
+	desc := lang.T("verify_log_passed", "user_id", userID)
+	if by != nil && by.ID != userID {
+		desc = lang.T("verify_log_approved", "user_id", userID, "mod_id", by.ID)
+	}
+	logModEvent(s, guildID, lang.T("verify_log_passed_title"), desc, 0x57F287)
+	return true
+}
+
+// failVerification kicks a member who timed out or ran out of attempts.
+func failVerification(s *discordgo.Session, guildID, userID, reason string) {
+	if !removePendingVerification(guildID, userID) {
+		return
+	}
+	cancelVerifyTimer(guildID, userID)
+
+	target, err := s.User(userID)
+	if err != nil {
+		target = &discordgo.User{ID: userID, Username: userID}
+	}
+	if err := s.GuildMemberDeleteWithReason(guildID, userID, reason); err != nil {
+		log.Printf("[Verify] Failed to kick %s from guild %s: %v", userID, guildID, err)
+		return
+	}
+	logModAction(s, guildID, "Kick (Verification)", target, s.State.User, reason, "")
+}
+
+func pendingVerificationIndex(gs *config.GuildState, userID string) int {
+	for idx := range gs.PendingVerifications {
+		if gs.PendingVerifications[idx].UserID == userID {
+			return idx
+		}
+	}
+	return -1
+}
+
+func removePendingVerification(guildID, userID string) bool {
+	gs := storage.GetGuild(guildID)
+	gs.Lock()
+	idx := pendingVerificationIndex(gs, userID)
+	if idx >= 0 {
+		gs.PendingVerifications = append(gs.PendingVerifications[:idx], gs.PendingVerifications[idx+1:]...)
+	}
+	gs.Unlock()
+	if idx < 0 {
+		return false
+	}
+	_ = gs.Save()
+	return true
+}
+
+// cancelVerification forgets a pending member, e.g. when they leave on their own.
+func cancelVerification(guildID, userID string) {
+	if removePendingVerification(guildID, userID) {
+		cancelVerifyTimer(guildID, userID)
+	}
+}
+
+func scheduleVerifyTimeout(s *discordgo.Session, guildID, userID string, dur time.Duration) {
+	key := guildID + ":" + userID
+	t := time.AfterFunc(dur, func() {
+		verifyTimersMu.Lock()
+		delete(verifyTimers, key)
+		verifyTimersMu.Unlock()
+		failVerification(s, guildID, userID, lang.T("verify_reason_timeout"))
+	})
+	verifyTimersMu.Lock()
+	if old, ok := verifyTimers[key]; ok {
+		old.Stop()
+	}
+	verifyTimers[key] = t
+	verifyTimersMu.Unlock()
+}
+
+func cancelVerifyTimer(guildID, userID string) {
+	key := guildID + ":" + userID
+	verifyTimersMu.Lock()
+	if t, ok := verifyTimers[key]; ok {
+		t.Stop()
+		delete(verifyTimers, key)
+	}
+	verifyTimersMu.Unlock()
+}
+
+// RestoreVerificationTimers reschedules verification timeouts after a restart
+// and kicks members whose deadline passed while the bot was offline.
+func RestoreVerificationTimers(s *discordgo.Session, gs *config.GuildState) {
+	gs.Lock()
+	guildID := gs.GuildID
+	pending := make([]config.PendingVerification, len(gs.PendingVerifications))
+	copy(pending, gs.PendingVerifications)
+	gs.Unlock()
+
+	for _, p := range pending {
+		if p.ExpiresAt == "" {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, p.ExpiresAt)
+		if err != nil {
+			continue
+		}
+		remaining := time.Until(expiresAt)
+		if remaining <= 0 {
+			go failVerification(s, guildID, p.UserID, lang.T("verify_reason_timeout"))
+		} else {
+			scheduleVerifyTimeout(s, guildID, p.UserID, remaining)
+		}
+	}
+}
+
+// generateCaptcha returns the prompt shown in the modal and the expected answer.
+func generateCaptcha(mode string) (prompt, answer string) {
+	if mode == "math" {
+		a, b := rand.Intn(20)+1, rand.Intn(20)+1
+		return lang.T("verify_prompt_math", "a", strconv.Itoa(a), "b", strconv.Itoa(b)), strconv.Itoa(a + b)
+	}
+	code := make([]byte, 6)
+	for idx := range code {
+		code[idx] = captchaAlphabet[rand.Intn(len(captchaAlphabet))]
+	}
+	return lang.T("verify_prompt_text", "code", string(code)), string(code)
+}
+
+// modalValue returns the value typed into a modal text input.
+func modalValue(data discordgo.ModalSubmitInteractionData, customID string) string {
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rc := range row.Components {
+			if ti, ok := rc.(*discordgo.TextInput); ok && ti.CustomID == customID {
+				return ti.Value
+			}
+		}
+	}
+	return ""
+}
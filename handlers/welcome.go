@@ -27,7 +27,19 @@ func RegisterWelcomeLeave(s *discordgo.Session) {
 	}
 
 	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
-		AssignJoinRole(s, m.GuildID, m.User.ID)
+		if gateJoin(s, m.GuildID, m.Member) {
+			return
+		}
+		if !startVerification(s, m.GuildID, m.Member) {
+			AssignJoinRole(s, m.GuildID, m.Member)
+		}
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		handleJoinRoleScreening(s, m)
+	})
+	s.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		cancelPendingJoinRole(m.GuildID, m.User.ID)
+		cancelVerification(m.GuildID, m.User.ID)
 	})
 }
 
@@ -62,6 +62,14 @@ func main() {
 	handlers.RegisterWelcomeLeave(b.Session)
 	handlers.RegisterNoPing(b.Session, cfg)
 	handlers.RegisterCounting(b.Session, cfg)
+	handlers.RegisterReactionRoles(b.Session)
+	handlers.RegisterStickyRoles(b.Session)
+	handlers.RegisterAntiRaid(b.Session, cfg)
+	handlers.RegisterJoinGate(b.Session, cfg)
+	handlers.RegisterNicknames(b.Session, cfg)
+	handlers.RegisterAudit(b.Session)
+	handlers.RegisterFilter(b.Session)
+	handlers.RegisterLinkFilter(b.Session)
 	handlers.RegisterCustomCommands(cfg)
 
 	if cfg.ChatBridge.Enabled {
@@ -104,6 +112,10 @@ func main() {
 		gs := storage.GetGuild(guildID)
 		handlers.RestoreGiveawayTimers(b.Session, gs)
 		log.Println("Giveaway timers restored.")
+		handlers.RestoreTempRoleTimers(b.Session, gs)
+		handlers.RestoreJoinRoleTimers(b.Session, gs)
+		handlers.RestoreVerificationTimers(b.Session, gs)
+		go handlers.SyncReactionRoles(b.Session, gs)
 	}
 
 	if cfg.Minecraft.Enabled {
@@ -7,6 +7,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"discord-bot/config"
 )
@@ -21,8 +25,20 @@ type Database interface {
 	GetWarnings(guildID, userID string) ([]config.Warning, error)
 	ClearWarnings(guildID, userID string) error
 
-	AddModCase(guildID string, c ModCase) error
+	AddModCase(guildID string, c ModCase) (int, error)
 	GetModCases(guildID, userID string, limit int) ([]ModCase, error)
+	GetModCase(guildID string, caseID int) (*ModCase, error)
+	// SetModCaseAppeal moves a case's appeal from status from to status and
+	// reports whether it did; false means the appeal was no longer in from,
+	// e.g. because another moderator decided it first.
+	SetModCaseAppeal(guildID string, caseID int, from, status, text, modID string) (bool, error)
+	// CountModActions counts cases per moderator and action since the given
+	// time (zero for all time), optionally for a single moderator.
+	CountModActions(guildID, modID string, since time.Time) ([]ModActionCount, error)
+
+	AddNote(guildID string, n ModNote) (int, error)
+	GetNotes(guildID, userID string) ([]ModNote, error)
+	RemoveNote(guildID, userID string, noteID int) (bool, error)
 }
 
 type ModCase struct {
@@ -34,6 +50,38 @@ type ModCase struct {
 	Reason    string `json:"reason"`
 	Duration  string `json:"duration,omitempty"`
 	Timestamp string `json:"timestamp"`
+
+	// Appeal fields; AppealStatus is "", "pending", "accepted" or "denied".
+	AppealStatus string `json:"appeal_status,omitempty"`
+	AppealText   string `json:"appeal_text,omitempty"`
+	AppealModID  string `json:"appeal_mod_id,omitempty"`
+}
+
+// ModActionCount is one row of a mod case aggregate. Numbered actions such as
+// "Warn (#3)" are counted under their base name.
+type ModActionCount struct {
+	ModID  string `json:"mod_id"`
+	Action string `json:"action"`
+	Count  int    `json:"count"`
+}
+
+// baseAction strips the per-user number from actions like "Warn (#3)".
+func baseAction(action string) string {
+	if idx := strings.Index(action, " (#"); idx > 0 {
+		return action[:idx]
+	}
+	return action
+}
+
+// ModNote is a private staff note about a user. Notes aren't punishments and
+// never show up as mod cases.
+type ModNote struct {
+	ID        int    `json:"id"`
+	GuildID   string `json:"guild_id"`
+	UserID    string `json:"user_id"`
+	ModID     string `json:"mod_id"`
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
 }
 
 func InitDB(cfg *config.DatabaseConfig) error {
@@ -92,14 +140,33 @@ func (s *SQLiteDB) Init() error {
 		action      TEXT NOT NULL,
 		reason      TEXT NOT NULL DEFAULT '',
 		duration    TEXT NOT NULL DEFAULT '',
-		timestamp   TEXT NOT NULL
+		timestamp   TEXT NOT NULL,
+		appeal_status TEXT NOT NULL DEFAULT '',
+		appeal_text   TEXT NOT NULL DEFAULT '',
+		appeal_mod_id TEXT NOT NULL DEFAULT ''
 	);
 	CREATE INDEX IF NOT EXISTS idx_mod_cases_guild_user ON mod_cases(guild_id, user_id);
+
+	CREATE TABLE IF NOT EXISTS mod_notes (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id    TEXT NOT NULL,
+		user_id     TEXT NOT NULL,
+		mod_id      TEXT NOT NULL,
+		content     TEXT NOT NULL,
+		timestamp   TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_mod_notes_guild_user ON mod_notes(guild_id, user_id);
 	`
 	_, err = db.Exec(schema)
 	if err != nil {
 		return fmt.Errorf("sqlite schema: %w", err)
 	}
+
+	// Databases created before appeals existed lack these columns; the
+	// "duplicate column" error on newer databases is expected.
+	for _, col := range []string{"appeal_status", "appeal_text", "appeal_mod_id"} {
+		_, _ = db.Exec("ALTER TABLE mod_cases ADD COLUMN " + col + " TEXT NOT NULL DEFAULT ''")
+	}
 	log.Printf("[DB] SQLite initialised at %s", s.Path)
 	return nil
 }
@@ -145,17 +212,21 @@ func (s *SQLiteDB) ClearWarnings(guildID, userID string) error {
 	return err
 }
 
-func (s *SQLiteDB) AddModCase(guildID string, c ModCase) error {
-	_, err := s.db.Exec(
+func (s *SQLiteDB) AddModCase(guildID string, c ModCase) (int, error) {
+	res, err := s.db.Exec(
 		"INSERT INTO mod_cases (guild_id, user_id, mod_id, action, reason, duration, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)",
 		guildID, c.UserID, c.ModID, c.Action, c.Reason, c.Duration, c.Timestamp,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
 }
 
 func (s *SQLiteDB) GetModCases(guildID, userID string, limit int) ([]ModCase, error) {
 	rows, err := s.db.Query(
-		"SELECT id, guild_id, user_id, mod_id, action, reason, duration, timestamp FROM mod_cases WHERE guild_id = ? AND user_id = ? ORDER BY id DESC LIMIT ?",
+		"SELECT id, guild_id, user_id, mod_id, action, reason, duration, timestamp, appeal_status, appeal_text, appeal_mod_id FROM mod_cases WHERE guild_id = ? AND user_id = ? ORDER BY id DESC LIMIT ?",
 		guildID, userID, limit,
 	)
 	if err != nil {
@@ -166,7 +237,7 @@ func (s *SQLiteDB) GetModCases(guildID, userID string, limit int) ([]ModCase, er
 	var cases []ModCase
 	for rows.Next() {
 		var c ModCase
-		if err := rows.Scan(&c.ID, &c.GuildID, &c.UserID, &c.ModID, &c.Action, &c.Reason, &c.Duration, &c.Timestamp); err != nil {
+		if err := rows.Scan(&c.ID, &c.GuildID, &c.UserID, &c.ModID, &c.Action, &c.Reason, &c.Duration, &c.Timestamp, &c.AppealStatus, &c.AppealText, &c.AppealModID); err != nil {
 			continue
 		}
 		cases = append(cases, c)
@@ -174,10 +245,119 @@ func (s *SQLiteDB) GetModCases(guildID, userID string, limit int) ([]ModCase, er
 	return cases, nil
 }
 
+func (s *SQLiteDB) GetModCase(guildID string, caseID int) (*ModCase, error) {
+	var c ModCase
+	err := s.db.QueryRow(
+		"SELECT id, guild_id, user_id, mod_id, action, reason, duration, timestamp, appeal_status, appeal_text, appeal_mod_id FROM mod_cases WHERE guild_id = ? AND id = ?",
+		guildID, caseID,
+	).Scan(&c.ID, &c.GuildID, &c.UserID, &c.ModID, &c.Action, &c.Reason, &c.Duration, &c.Timestamp, &c.AppealStatus, &c.AppealText, &c.AppealModID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *SQLiteDB) SetModCaseAppeal(guildID string, caseID int, from, status, text, modID string) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE mod_cases SET appeal_status = ?, appeal_text = ?, appeal_mod_id = ? WHERE guild_id = ? AND id = ? AND appeal_status = ?",
+		status, text, modID, guildID, caseID, from,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLiteDB) CountModActions(guildID, modID string, since time.Time) ([]ModActionCount, error) {
+	query := `
+	SELECT mod_id,
+		CASE WHEN instr(action, ' (#') > 0 THEN substr(action, 1, instr(action, ' (#') - 1) ELSE action END AS base,
+		COUNT(*)
+	FROM mod_cases
+	WHERE guild_id = ?`
+	args := []interface{}{guildID}
+	if modID != "" {
+		query += " AND mod_id = ?"
+		args = append(args, modID)
+	}
+	if !since.IsZero() {
+		query += " AND julianday(timestamp) >= julianday(?)"
+		args = append(args, since.Format(time.RFC3339))
+	}
+	query += " GROUP BY mod_id, base ORDER BY COUNT(*) DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ModActionCount
+	for rows.Next() {
+		var c ModActionCount
+		if err := rows.Scan(&c.ModID, &c.Action, &c.Count); err != nil {
+			continue
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLiteDB) AddNote(guildID string, n ModNote) (int, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO mod_notes (guild_id, user_id, mod_id, content, timestamp) VALUES (?, ?, ?, ?, ?)",
+		guildID, n.UserID, n.ModID, n.Content, n.Timestamp,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (s *SQLiteDB) GetNotes(guildID, userID string) ([]ModNote, error) {
+	rows, err := s.db.Query(
+		"SELECT id, guild_id, user_id, mod_id, content, timestamp FROM mod_notes WHERE guild_id = ? AND user_id = ? ORDER BY id",
+		guildID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []ModNote
+	for rows.Next() {
+		var n ModNote
+		if err := rows.Scan(&n.ID, &n.GuildID, &n.UserID, &n.ModID, &n.Content, &n.Timestamp); err != nil {
+			continue
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+func (s *SQLiteDB) RemoveNote(guildID, userID string, noteID int) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM mod_notes WHERE guild_id = ? AND user_id = ? AND id = ?", guildID, userID, noteID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 type MongoDB struct {
 	URI    string
 	DBName string
 	dir    string
+
+	// casesMu serialises read-modify-write cycles on the case files.
+	casesMu sync.Mutex
+	// notesMu does the same for the note files.
+	notesMu sync.Mutex
 }
 
 func (m *MongoDB) Init() error {
@@ -230,12 +410,15 @@ func (m *MongoDB) ClearWarnings(guildID, userID string) error {
 	return os.Remove(path)
 }
 
-func (m *MongoDB) AddModCase(guildID string, c ModCase) error {
+func (m *MongoDB) AddModCase(guildID string, c ModCase) (int, error) {
+	m.casesMu.Lock()
+	defer m.casesMu.Unlock()
+
 	var cases []ModCase
 	_ = m.loadCollection("modcases_"+guildID, &cases)
 	c.ID = len(cases) + 1
 	cases = append(cases, c)
-	return m.saveCollection("modcases_"+guildID, &cases)
+	return c.ID, m.saveCollection("modcases_"+guildID, &cases)
 }
 
 func (m *MongoDB) GetModCases(guildID, userID string, limit int) ([]ModCase, error) {
@@ -250,3 +433,134 @@ func (m *MongoDB) GetModCases(guildID, userID string, limit int) ([]ModCase, err
 	}
 	return filtered, nil
 }
+
+func (m *MongoDB) GetModCase(guildID string, caseID int) (*ModCase, error) {
+	var all []ModCase
+	_ = m.loadCollection("modcases_"+guildID, &all)
+	for idx := range all {
+		if all[idx].ID == caseID {
+			return &all[idx], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MongoDB) SetModCaseAppeal(guildID string, caseID int, from, status, text, modID string) (bool, error) {
+	m.casesMu.Lock()
+	defer m.casesMu.Unlock()
+
+	var all []ModCase
+	_ = m.loadCollection("modcases_"+guildID, &all)
+	for idx := range all {
+		if all[idx].ID == caseID {
+			if all[idx].AppealStatus != from {
+				return false, nil
+			}
+			all[idx].AppealStatus = status
+			all[idx].AppealText = text
+			all[idx].AppealModID = modID
+			return true, m.saveCollection("modcases_"+guildID, &all)
+		}
+	}
+	return false, fmt.Errorf("case #%d not found", caseID)
+}
+
+func (m *MongoDB) AddNote(guildID string, n ModNote) (int, error) {
+	m.notesMu.Lock()
+	defer m.notesMu.Unlock()
+
+	var notes []ModNote
+	_ = m.loadCollection("notes_"+guildID, &notes)
+	// Notes can be removed, so take the next ID after the highest one.
+	n.ID = 1
+	for _, existing := range notes {
+		if existing.ID >= n.ID {
+			n.ID = existing.ID + 1
+		}
+	}
+	notes = append(notes, n)
+	return n.ID, m.saveCollection("notes_"+guildID, &notes)
+}
+
+func (m *MongoDB) GetNotes(guildID, userID string) ([]ModNote, error) {
+	m.notesMu.Lock()
+	defer m.notesMu.Unlock()
+
+	var all []ModNote
+	_ = m.loadCollection("notes_"+guildID, &all)
+
+	var notes []ModNote
+	for _, n := range all {
+		if n.UserID == userID {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+func (m *MongoDB) RemoveNote(guildID, userID string, noteID int) (bool, error) {
+	m.notesMu.Lock()
+	defer m.notesMu.Unlock()
+
+	var all []ModNote
+	_ = m.loadCollection("notes_"+guildID, &all)
+	for idx, n := range all {
+		if n.ID == noteID && n.UserID == userID {
+			all = append(all[:idx], all[idx+1:]...)
+			return true, m.saveCollection("notes_"+guildID, &all)
+		}
+	}
+	return false, nil
+}
+
+// CountModActions streams the case file and keeps only running totals, so
+// large guilds don't need the whole collection in memory.
+func (m *MongoDB) CountModActions(guildID, modID string, since time.Time) ([]ModActionCount, error) {
+	// Hold the lock while reading: saveCollection truncates before writing.
+	m.casesMu.Lock()
+	defer m.casesMu.Unlock()
+
+	f, err := os.Open(m.collectionPath("modcases_" + guildID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	if _, err := dec.Token(); err != nil { // opening '['
+		return nil, nil
+	}
+
+	type key struct{ mod, action string }
+	totals := make(map[key]int)
+	var order []key
+	for dec.More() {
+		var c ModCase
+		if err := dec.Decode(&c); err != nil {
+			return nil, err
+		}
+		if modID != "" && c.ModID != modID {
+			continue
+		}
+		if !since.IsZero() {
+			if t, err := time.Parse(time.RFC3339, c.Timestamp); err != nil || t.Before(since) {
+				continue
+			}
+		}
+		k := key{c.ModID, baseAction(c.Action)}
+		if _, seen := totals[k]; !seen {
+			order = append(order, k)
+		}
+		totals[k]++
+	}
+
+	counts := make([]ModActionCount, 0, len(order))
+	for _, k := range order {
+		counts = append(counts, ModActionCount{ModID: k.mod, Action: k.action, Count: totals[k]})
+	}
+	sort.SliceStable(counts, func(a, b int) bool { return counts[a].Count > counts[b].Count })
+	return counts, nil
+}